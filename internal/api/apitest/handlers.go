@@ -0,0 +1,408 @@
+package apitest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"mihomoTui/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxHistory bounds the delay history kept per proxy
+const maxHistory = 10
+
+// routes registers the controller endpoints
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"hello": "mihomo"})
+	})
+	s.mux.HandleFunc("GET /version", s.handleVersion)
+
+	s.mux.HandleFunc("GET /configs", s.handleGetConfig)
+	s.mux.HandleFunc("PATCH /configs", s.handlePatchConfig)
+	s.mux.HandleFunc("PUT /configs", noContent)
+	s.mux.HandleFunc("POST /configs/geo", noContent)
+	s.mux.HandleFunc("POST /restart", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	s.mux.HandleFunc("POST /cache/fakeip/flush", noContent)
+	s.mux.HandleFunc("POST /cache/dns/flush", noContent)
+
+	s.mux.HandleFunc("GET /proxies", s.handleGetProxies)
+	s.mux.HandleFunc("GET /proxies/{name}", s.handleGetProxy)
+	s.mux.HandleFunc("PUT /proxies/{name}", s.handleSelectProxy)
+	s.mux.HandleFunc("GET /proxies/{name}/delay", s.handleProxyDelay)
+	s.mux.HandleFunc("GET /group", s.handleGetGroups)
+	s.mux.HandleFunc("GET /group/{name}/delay", s.handleGroupDelay)
+
+	s.mux.HandleFunc("GET /providers/proxies", s.handleGetProviders)
+	s.mux.HandleFunc("PUT /providers/proxies/{name}", s.handleUpdateProvider)
+	s.mux.HandleFunc("GET /providers/proxies/{name}/healthcheck", s.handleProviderHealthCheck)
+	s.mux.HandleFunc("GET /providers/rules", s.handleGetRuleProviders)
+	s.mux.HandleFunc("PUT /providers/rules/{name}", s.handleUpdateRuleProvider)
+
+	s.mux.HandleFunc("GET /rules", s.handleGetRules)
+	s.mux.HandleFunc("GET /connections", s.handleConnections)
+	s.mux.HandleFunc("DELETE /connections", s.handleCloseAllConnections)
+	s.mux.HandleFunc("DELETE /connections/{id}", s.handleCloseConnection)
+	s.mux.HandleFunc("GET /dns/query", s.handleDNSQuery)
+
+	s.mux.HandleFunc("GET /traffic", s.serveStream(StreamTraffic))
+	s.mux.HandleFunc("GET /logs", s.serveStream(StreamLogs))
+	s.mux.HandleFunc("GET /memory", s.serveStream(StreamMemory))
+}
+
+func noContent(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, s.state.Version)
+}
+
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, s.state.Config)
+}
+
+// handlePatchConfig merges the keys sent into the config, descending into
+// objects such as tun the way mihomo does
+func (s *Server) handlePatchConfig(w http.ResponseWriter, r *http.Request) {
+	var patch map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current := make(map[string]any)
+	data, _ := json.Marshal(s.state.Config)
+	json.Unmarshal(data, &current)
+	merge(current, patch)
+
+	var config models.Config
+	data, _ = json.Marshal(current)
+	if err := json.Unmarshal(data, &config); err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.state.Config = config
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// merge copies src into dst, merging nested objects key by key
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		if nested, ok := value.(map[string]any); ok {
+			if existing, ok := dst[key].(map[string]any); ok {
+				merge(existing, nested)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+func (s *Server) handleGetProxies(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"proxies": s.state.Proxies})
+}
+
+func (s *Server) handleGetProxy(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	proxy, ok := s.state.Proxies[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, proxy)
+}
+
+func (s *Server) handleSelectProxy(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group, ok := s.state.Proxies[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	if group.All == nil {
+		writeMessage(w, http.StatusBadRequest, "Must be a Selector")
+		return
+	}
+	if !slices.Contains(group.All, body.Name) {
+		writeMessage(w, http.StatusBadRequest, "Selector update error: proxy not exist")
+		return
+	}
+	group.Now = body.Name
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleProxyDelay(w http.ResponseWriter, r *http.Request) {
+	if err := checkDelayParams(r); err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	proxy, ok := s.state.Proxies[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+
+	delay := s.testDelay(proxy)
+	if delay <= 0 {
+		writeMessage(w, http.StatusGatewayTimeout, "Timeout")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"delay": delay})
+}
+
+func (s *Server) handleGetGroups(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var groups []*models.Proxy
+	for _, proxy := range s.state.Proxies {
+		if proxy.All != nil {
+			groups = append(groups, proxy)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"proxies": groups})
+}
+
+// handleGroupDelay tests every member of a group and answers the delays of
+// those that responded
+func (s *Server) handleGroupDelay(w http.ResponseWriter, r *http.Request) {
+	if err := checkDelayParams(r); err != nil {
+		writeMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	group, ok := s.state.Proxies[r.PathValue("name")]
+	if !ok || group.All == nil {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+
+	delays := make(map[string]int)
+	for _, name := range group.All {
+		if proxy, ok := s.state.Proxies[name]; ok {
+			if delay := s.testDelay(proxy); delay > 0 {
+				delays[name] = delay
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, delays)
+}
+
+// checkDelayParams checks the url and timeout parameters of a delay test
+func checkDelayParams(r *http.Request) error {
+	query := r.URL.Query()
+	if _, err := strconv.Atoi(query.Get("timeout")); err != nil || query.Get("url") == "" {
+		return errors.New("Format error")
+	}
+	return nil
+}
+
+// testDelay answers the scripted delay of proxy, following groups to their
+// selected member, and records it in the history. The caller holds mutex.
+func (s *Server) testDelay(proxy *models.Proxy) int {
+	target := proxy
+	for seen := 0; target.All != nil && seen < len(s.state.Proxies); seen++ {
+		next, ok := s.state.Proxies[target.Now]
+		if !ok {
+			break
+		}
+		target = next
+	}
+
+	delay := s.state.Delays[target.Name]
+	if delay < 0 {
+		delay = 0
+	}
+	proxy.History = append(proxy.History, models.ProxyHistory{Time: time.Now(), Delay: delay})
+	if len(proxy.History) > maxHistory {
+		proxy.History = proxy.History[len(proxy.History)-maxHistory:]
+	}
+	return delay
+}
+
+func (s *Server) handleGetProviders(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, models.ProvidersResponse{Providers: s.state.Providers})
+}
+
+func (s *Server) handleUpdateProvider(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	provider, ok := s.state.Providers[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	provider.UpdatedAt = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleProviderHealthCheck(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	provider, ok := s.state.Providers[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	for _, proxy := range provider.Proxies {
+		if known, ok := s.state.Proxies[proxy.Name]; ok {
+			s.testDelay(known)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetRuleProviders(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, models.RuleProvidersResponse{Providers: s.state.RuleProviders})
+}
+
+func (s *Server) handleUpdateRuleProvider(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	provider, ok := s.state.RuleProviders[r.PathValue("name")]
+	if !ok {
+		writeMessage(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	provider.UpdatedAt = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"rules": s.state.Rules})
+}
+
+// connectionsSnapshot returns the /connections payload. The caller holds
+// mutex.
+func (s *Server) connectionsSnapshot() models.ConnectionsSnapshot {
+	return models.ConnectionsSnapshot{
+		DownloadTotal: s.state.DownloadTotal,
+		UploadTotal:   s.state.UploadTotal,
+		Connections:   append([]models.Connection{}, s.state.Connections...),
+		Memory:        s.state.Memory,
+	}
+}
+
+// handleConnections answers a single snapshot over plain HTTP, and a
+// snapshot every interval milliseconds over WebSocket
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		s.mutex.Lock()
+		snapshot := s.connectionsSnapshot()
+		s.mutex.Unlock()
+		writeJSON(w, http.StatusOK, snapshot)
+		return
+	}
+
+	interval := time.Second
+	if ms, err := strconv.Atoi(r.URL.Query().Get("interval")); err == nil && ms > 0 {
+		interval = time.Duration(ms) * time.Millisecond
+	}
+
+	s.writeStream(w, r, func(send func([]byte) bool, stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.mutex.Lock()
+			data, _ := json.Marshal(s.connectionsSnapshot())
+			s.mutex.Unlock()
+			if !send(data) {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+func (s *Server) handleCloseAllConnections(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.state.Connections = nil
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleCloseConnection(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// mihomo answers 204 for unknown ids too
+	id := r.PathValue("id")
+	s.state.Connections = slices.DeleteFunc(s.state.Connections, func(conn models.Connection) bool {
+		return conn.ID == id
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDNSQuery answers from State.DNS, with NXDOMAIN for unknown names
+func (s *Server) handleDNSQuery(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeMessage(w, http.StatusBadRequest, "Body invalid")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := models.DNSQueryResult{
+		Question: []models.DNSQuestion{{Name: name + ".", Qtype: 1, Qclass: 1}},
+		RD:       true,
+		RA:       true,
+	}
+	if answer, ok := s.state.DNS[name]; ok {
+		result.Answer = answer
+	} else {
+		result.Status = 3
+	}
+	writeJSON(w, http.StatusOK, result)
+}
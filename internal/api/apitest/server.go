@@ -0,0 +1,360 @@
+// Package apitest provides an in-process fake mihomo controller for tests
+// and demos. It serves the REST endpoints the TUI uses from a scriptable
+// State, streams /traffic, /logs, /memory and /connections over WebSocket
+// or chunked HTTP, and records every request it receives.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"mihomoTui/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// Stream names accepted by Publish
+const (
+	StreamTraffic = "traffic"
+	StreamLogs    = "logs"
+	StreamMemory  = "memory"
+)
+
+// subscriberBuffer bounds the messages queued for a slow stream reader;
+// further messages are dropped, as a real core does
+const subscriberBuffer = 64
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string // Unescaped path, e.g. /proxies/US/LA #3/delay
+	Query  string // Raw query string
+	Body   []byte
+}
+
+// failure is a scripted error response
+type failure struct {
+	status  int
+	message string
+}
+
+// Server is a fake controller listening on a local port
+type Server struct {
+	*httptest.Server
+
+	mutex       sync.Mutex
+	state       *State
+	secret      string
+	noWebSocket bool
+	disabled    []string           // Path prefixes answered with 404
+	failures    map[string]failure // By "METHOD /path"
+	requests    []Request
+	subscribers map[string]map[chan []byte]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+	upgrader    websocket.Upgrader
+	mux         *http.ServeMux
+}
+
+// NewServer starts a controller serving SampleState. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		state:       SampleState(),
+		failures:    make(map[string]failure),
+		subscribers: make(map[string]map[chan []byte]struct{}),
+		done:        make(chan struct{}),
+		mux:         http.NewServeMux(),
+	}
+	s.routes()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close ends open streams and shuts the server down
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.Server.Close()
+}
+
+// Update changes the state under the server's lock
+func (s *Server) Update(fn func(state *State)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fn(s.state)
+}
+
+// View reads the state under the server's lock
+func (s *Server) View(fn func(state *State)) {
+	s.Update(fn)
+}
+
+// SetSecret makes the server require secret, empty for none
+func (s *Server) SetSecret(secret string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.secret = secret
+}
+
+// DisableWebSocket makes the server refuse upgrades like a reverse proxy
+// that doesn't forward them, so streams fall back to chunked HTTP
+func (s *Server) DisableWebSocket() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.noWebSocket = true
+}
+
+// Disable answers 404 for every path starting with one of prefixes, to
+// emulate cores that lack an endpoint
+func (s *Server) Disable(prefixes ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.disabled = append(s.disabled, prefixes...)
+}
+
+// Fail answers requests for method and the unescaped path with status and
+// a {"message"} body until ClearFailures is called
+func (s *Server) Fail(method, path string, status int, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[method+" "+path] = failure{status: status, message: message}
+}
+
+// ClearFailures removes every failure set with Fail
+func (s *Server) ClearFailures() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = make(map[string]failure)
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// LastRequest returns the latest request for method and the unescaped
+// path, and whether there was one
+func (s *Server) LastRequest(method, path string) (Request, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if r := s.requests[i]; r.Method == method && r.Path == path {
+			return r, true
+		}
+	}
+	return Request{}, false
+}
+
+// Publish sends a message to every client reading stream
+func (s *Server) Publish(stream string, message any) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for ch := range s.subscribers[stream] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// PublishTraffic sends a traffic sample to /traffic readers
+func (s *Server) PublishTraffic(up, down int64) {
+	s.Publish(StreamTraffic, models.Traffic{Up: up, Down: down})
+}
+
+// PublishLog sends a log line to /logs readers
+func (s *Server) PublishLog(level, payload string) {
+	s.Publish(StreamLogs, models.Log{Type: level, Payload: payload})
+}
+
+// PublishMemory sends a memory sample to /memory readers
+func (s *Server) PublishMemory(inuse int64) {
+	s.Publish(StreamMemory, models.MemoryUsage{Inuse: inuse})
+}
+
+// WaitSubscribers waits until at least n clients read stream, so tests
+// don't publish before the client is listening
+func (s *Server) WaitSubscribers(stream string, n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.mutex.Lock()
+		count := len(s.subscribers[stream])
+		s.mutex.Unlock()
+		if count >= n {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// serveHTTP records the request and applies the scripted behaviour before
+// routing it
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: body})
+	secret := s.secret
+	disabled := s.disabled
+	fail, failing := s.failures[r.Method+" "+r.URL.Path]
+	s.mutex.Unlock()
+
+	// WebSocket clients may pass the secret as ?token=
+	if secret != "" && r.Header.Get("Authorization") != "Bearer "+secret && r.URL.Query().Get("token") != secret {
+		writeMessage(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	for _, prefix := range disabled {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	if failing {
+		writeMessage(w, fail.status, fail.message)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.mux.ServeHTTP(w, r)
+}
+
+// subscribe registers a reader of stream
+func (s *Server) subscribe(stream string) (chan []byte, func()) {
+	ch := make(chan []byte, subscriberBuffer)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.subscribers[stream] == nil {
+		s.subscribers[stream] = make(map[chan []byte]struct{})
+	}
+	s.subscribers[stream][ch] = struct{}{}
+
+	return ch, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.subscribers[stream], ch)
+	}
+}
+
+// serveStream delivers the messages published on stream until the client
+// goes away or the server closes
+func (s *Server) serveStream(stream string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		messages, unsubscribe := s.subscribe(stream)
+		defer unsubscribe()
+
+		s.writeStream(w, r, func(send func([]byte) bool, stop <-chan struct{}) {
+			for {
+				select {
+				case data := <-messages:
+					if !send(data) {
+						return
+					}
+				case <-stop:
+					return
+				}
+			}
+		})
+	}
+}
+
+// writeStream upgrades to WebSocket when the client asks for it and the
+// server allows it, and writes newline-delimited JSON otherwise. produce
+// calls send for each message until send returns false or stop is closed
+// by the client going away or the server closing.
+func (s *Server) writeStream(w http.ResponseWriter, r *http.Request, produce func(send func([]byte) bool, stop <-chan struct{})) {
+	s.mutex.Lock()
+	noWebSocket := s.noWebSocket
+	s.mutex.Unlock()
+
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopStream := func() {
+		stopOnce.Do(func() { close(stop) })
+	}
+	go func() {
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		case <-stop:
+			return
+		}
+		stopStream()
+	}()
+	defer stopStream()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		if noWebSocket {
+			writeMessage(w, http.StatusBadRequest, "websocket not supported")
+			return
+		}
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// The request context outlives a hijacked connection, so watch
+		// for the client hanging up instead
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					stopStream()
+					return
+				}
+			}
+		}()
+
+		produce(func(data []byte) bool {
+			return conn.WriteMessage(websocket.TextMessage, data) == nil
+		}, stop)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	produce(func(data []byte) bool {
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}, stop)
+}
+
+// writeJSON answers with a JSON body
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeMessage answers with the {"message"} body mihomo uses for errors
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, models.APIResponse{Message: message})
+}
@@ -0,0 +1,131 @@
+package apitest
+
+import (
+	"time"
+
+	"mihomoTui/internal/models"
+)
+
+// State is everything the fake controller reports. Tests change it through
+// Server.Update and read it back through Server.View.
+type State struct {
+	Version models.Version
+	Config  models.Config
+
+	Proxies       map[string]*models.Proxy
+	Providers     map[string]*models.ProxyProvider
+	RuleProviders map[string]*models.RuleProvider
+	Rules         []models.Rule
+
+	Connections   []models.Connection
+	UploadTotal   int64
+	DownloadTotal int64
+	Memory        int64 // In use, as reported next to the connections
+
+	// Delay answered when a proxy is tested, in milliseconds. Proxies
+	// without a positive delay time out.
+	Delays map[string]int
+
+	// Records answered by the DNS query endpoint, by domain name
+	DNS map[string][]models.DNSRecord
+}
+
+// SampleState returns a small but realistic mihomo setup: a selector and
+// an url-test group over three nodes from one subscription, a rule set,
+// a handful of rules and two open connections.
+func SampleState() *State {
+	now := time.Now()
+	nodes := []string{"🇭🇰 香港 01", "🇯🇵 日本 02", "US/LA #3"}
+
+	proxies := map[string]*models.Proxy{
+		"DIRECT":  {Name: "DIRECT", Type: "Direct", UDP: true},
+		"REJECT":  {Name: "REJECT", Type: "Reject", UDP: true},
+		"GLOBAL":  {Name: "GLOBAL", Type: "Selector", UDP: true, Now: "DIRECT", All: []string{"DIRECT", "REJECT", "🚀 节点选择", "♻️ 自动选择"}},
+		"🚀 节点选择":  {Name: "🚀 节点选择", Type: "Selector", UDP: true, Now: "♻️ 自动选择", All: append([]string{"♻️ 自动选择", "DIRECT"}, nodes...)},
+		"♻️ 自动选择": {Name: "♻️ 自动选择", Type: "URLTest", UDP: true, Now: nodes[0], All: nodes},
+	}
+	providerProxies := make([]*models.Proxy, 0, len(nodes))
+	for _, name := range nodes {
+		proxy := &models.Proxy{Name: name, Type: "Trojan", UDP: true}
+		proxies[name] = proxy
+		providerProxies = append(providerProxies, proxy)
+	}
+
+	return &State{
+		Version: models.Version{Meta: true, Version: "v1.19.0"},
+		Config: models.Config{
+			Port:      7890,
+			SocksPort: 7891,
+			MixedPort: 7893,
+			Mode:      "rule",
+			LogLevel:  "info",
+			Tun: models.TunConfig{
+				Stack:               "mixed",
+				Device:              "Meta",
+				AutoRoute:           true,
+				AutoDetectInterface: true,
+				DNSHijack:           []string{"any:53"},
+				MTU:                 9000,
+			},
+		},
+		Proxies: proxies,
+		Providers: map[string]*models.ProxyProvider{
+			"subscription": {
+				Name:        "subscription",
+				Type:        "Proxy",
+				VehicleType: "HTTP",
+				Proxies:     providerProxies,
+				UpdatedAt:   now.Add(-time.Hour),
+				SubscriptionInfo: &models.SubscriptionInfo{
+					Upload:   1 << 30,
+					Download: 20 << 30,
+					Total:    200 << 30,
+					Expire:   now.AddDate(0, 1, 0).Unix(),
+				},
+			},
+		},
+		RuleProviders: map[string]*models.RuleProvider{
+			"reject": {Name: "reject", Type: "Rule", Behavior: "Domain", Format: "YamlRule", VehicleType: "HTTP", RuleCount: 120, UpdatedAt: now.Add(-24 * time.Hour)},
+		},
+		Rules: []models.Rule{
+			{Type: "RuleSet", Payload: "reject", Proxy: "REJECT"},
+			{Type: "DomainSuffix", Payload: "google.com", Proxy: "🚀 节点选择"},
+			{Type: "GeoIP", Payload: "CN", Proxy: "DIRECT"},
+			{Type: "Match", Payload: "", Proxy: "🚀 节点选择"},
+		},
+		Connections: []models.Connection{
+			{
+				ID:          "1b7e3c9a-0001",
+				Metadata:    models.ConnectionMetadata{Network: "tcp", Type: "HTTP", SourceIP: "127.0.0.1", SourcePort: "50123", DestinationPort: "443", Host: "www.google.com"},
+				Upload:      2048,
+				Download:    65536,
+				Start:       now.Add(-time.Minute),
+				Chains:      []string{nodes[0], "♻️ 自动选择", "🚀 节点选择"},
+				Rule:        "DomainSuffix",
+				RulePayload: "google.com",
+			},
+			{
+				ID:          "1b7e3c9a-0002",
+				Metadata:    models.ConnectionMetadata{Network: "udp", Type: "Socks5", SourceIP: "127.0.0.1", SourcePort: "50124", DestinationIP: "223.5.5.5", DestinationPort: "53"},
+				Upload:      512,
+				Download:    1024,
+				Start:       now.Add(-10 * time.Second),
+				Chains:      []string{"DIRECT"},
+				Rule:        "GeoIP",
+				RulePayload: "CN",
+			},
+		},
+		UploadTotal:   1 << 20,
+		DownloadTotal: 16 << 20,
+		Memory:        48 << 20,
+		Delays: map[string]int{
+			"DIRECT": 12,
+			nodes[0]: 86,
+			nodes[1]: 142,
+			// nodes[2] is down
+		},
+		DNS: map[string][]models.DNSRecord{
+			"example.com": {{Name: "example.com.", Type: 1, TTL: 300, Data: "93.184.215.14"}},
+		},
+	}
+}
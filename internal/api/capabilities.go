@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"mihomoTui/internal/models"
+)
+
+// Feature is an optional part of the controller API
+type Feature int
+
+const (
+	FeatureProxyProviders Feature = iota // GET /providers/proxies
+	FeatureRuleProviders                 // GET /providers/rules
+	FeatureGroupDelay                    // GET /group/{name}/delay
+	FeatureMemory                        // /memory stream
+	FeatureDNSQuery                      // GET /dns/query
+	FeatureRestart                       // POST /restart
+	FeatureGeoUpdate                     // POST /configs/geo
+	FeatureFlushFakeIP                   // POST /cache/fakeip/flush
+	FeatureFlushDNS                      // POST /cache/dns/flush
+	featureCount
+)
+
+// String returns the name of the feature
+func (f Feature) String() string {
+	switch f {
+	case FeatureProxyProviders:
+		return "proxy providers"
+	case FeatureRuleProviders:
+		return "rule providers"
+	case FeatureGroupDelay:
+		return "group delay"
+	case FeatureMemory:
+		return "memory"
+	case FeatureDNSQuery:
+		return "dns query"
+	case FeatureRestart:
+		return "restart"
+	case FeatureGeoUpdate:
+		return "geo update"
+	case FeatureFlushFakeIP:
+		return "fakeip flush"
+	case FeatureFlushDNS:
+		return "dns flush"
+	default:
+		return "unknown"
+	}
+}
+
+// CoreKind identifies the implementation behind the controller
+type CoreKind int
+
+const (
+	CoreUnknown CoreKind = iota
+	CoreMihomo
+	CoreClashPremium
+	CoreClash
+	CoreSingBox
+)
+
+// String returns the name of the core
+func (k CoreKind) String() string {
+	switch k {
+	case CoreMihomo:
+		return "mihomo"
+	case CoreClashPremium:
+		return "Clash Premium"
+	case CoreClash:
+		return "Clash"
+	case CoreSingBox:
+		return "sing-box"
+	default:
+		return "unknown"
+	}
+}
+
+// inferredFeatures lists the cores known to implement features that can't
+// be probed without side effects
+var inferredFeatures = map[Feature][]CoreKind{
+	FeatureRestart:     {CoreMihomo},
+	FeatureGeoUpdate:   {CoreMihomo},
+	FeatureFlushFakeIP: {CoreMihomo, CoreSingBox},
+	FeatureFlushDNS:    {CoreMihomo},
+}
+
+// probedFeatures maps features to a harmless GET that answers 404 when the
+// feature is missing
+var probedFeatures = map[Feature]string{
+	FeatureProxyProviders: "/providers/proxies",
+	FeatureRuleProviders:  "/providers/rules",
+	FeatureGroupDelay:     "/group",
+	FeatureMemory:         "/memory",
+	FeatureDNSQuery:       newPath("dns", "query").Param("name", "localhost").String(),
+}
+
+// Capabilities records what the connected core supports
+type Capabilities struct {
+	Core    CoreKind
+	Version string
+
+	probed    bool
+	supported [featureCount]bool
+}
+
+// Has reports whether the core supports f. Before a successful probe
+// every feature is assumed present, so requests fail the way they used to.
+func (c Capabilities) Has(f Feature) bool {
+	return !c.probed || c.supported[f]
+}
+
+// Probed reports whether the capabilities come from a successful probe
+func (c Capabilities) Probed() bool {
+	return c.probed
+}
+
+// capabilityCache holds the last probe result of a client
+type capabilityCache struct {
+	probeMutex sync.Mutex // Serialises probes so concurrent callers share one
+
+	mutex sync.Mutex
+	caps  *Capabilities
+}
+
+// Capabilities returns the features of the core, probing it on first use.
+// If the probe fails every feature is assumed present.
+func (c *HttpClient) Capabilities(ctx context.Context) Capabilities {
+	c.caps.probeMutex.Lock()
+	defer c.caps.probeMutex.Unlock()
+
+	c.caps.mutex.Lock()
+	cached := c.caps.caps
+	c.caps.mutex.Unlock()
+	if cached != nil {
+		return *cached
+	}
+
+	caps, err := c.probeCapabilities(ctx)
+	if err != nil {
+		slog.Warn("Failed to probe core capabilities", "err", err)
+	}
+	return caps
+}
+
+// ProbeCapabilities probes the core again, for instance after it came back
+// from a restart that may have replaced it, and caches the result
+func (c *HttpClient) ProbeCapabilities(ctx context.Context) (Capabilities, error) {
+	c.caps.probeMutex.Lock()
+	defer c.caps.probeMutex.Unlock()
+
+	return c.probeCapabilities(ctx)
+}
+
+// probeCapabilities identifies the core and checks which optional
+// endpoints exist. The caller holds probeMutex.
+func (c *HttpClient) probeCapabilities(ctx context.Context) (Capabilities, error) {
+	version, err := c.GetVersion(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	caps := Capabilities{
+		Core:    coreKind(version),
+		Version: version.Version,
+		probed:  true,
+	}
+	for feature, cores := range inferredFeatures {
+		for _, core := range cores {
+			if core == caps.Core {
+				caps.supported[feature] = true
+			}
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		probeErr error
+	)
+	for feature, endpoint := range probedFeatures {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			supported, err := c.probeEndpoint(ctx, endpoint)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				probeErr = err
+			}
+			caps.supported[feature] = supported
+		}()
+	}
+	wg.Wait()
+	if probeErr != nil {
+		return Capabilities{}, probeErr
+	}
+
+	c.caps.mutex.Lock()
+	c.caps.caps = &caps
+	c.caps.mutex.Unlock()
+
+	var missing []string
+	for feature := range featureCount {
+		if !caps.supported[feature] {
+			missing = append(missing, feature.String())
+		}
+	}
+	slog.Info("Probed core", "core", caps.Core, "version", caps.Version, "missing", strings.Join(missing, ", "))
+
+	return caps, nil
+}
+
+// probeEndpoint reports whether the controller serves endpoint. Only the
+// status is read, so streaming endpoints can be probed too.
+func (c *HttpClient) probeEndpoint(ctx context.Context, endpoint string) (bool, error) {
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	// Closed on return, as newStatusError still reads the core's message
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, newStatusError(resp)
+	default:
+		return true, nil
+	}
+}
+
+// coreKind tells the cores apart from their version response. sing-box
+// also claims meta and premium, so its version string is checked first.
+func coreKind(version *models.Version) CoreKind {
+	switch {
+	case strings.Contains(version.Version, "sing-box"):
+		return CoreSingBox
+	case version.Meta:
+		return CoreMihomo
+	case version.Premium:
+		return CoreClashPremium
+	default:
+		return CoreClash
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newCoreServer serves /version and answers 404 for endpoints not listed
+func newCoreServer(t *testing.T, version map[string]any, endpoints ...string) *HttpClient {
+	t.Helper()
+
+	served := map[string]bool{"/version": true}
+	for _, endpoint := range endpoints {
+		served[endpoint] = true
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !served[r.URL.Path]:
+			http.NotFound(w, r)
+		case r.URL.Path == "/version":
+			json.NewEncoder(w).Encode(version)
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &HttpClient{baseURL: server.URL, httpClient: server.Client()}
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   map[string]any
+		endpoints []string
+		core      CoreKind
+		has       []Feature
+		lacks     []Feature
+	}{
+		{
+			name:      "mihomo",
+			version:   map[string]any{"meta": true, "version": "v1.19.0"},
+			endpoints: []string{"/providers/proxies", "/providers/rules", "/group", "/memory", "/dns/query"},
+			core:      CoreMihomo,
+			has:       []Feature{FeatureProxyProviders, FeatureGroupDelay, FeatureMemory, FeatureDNSQuery, FeatureRestart, FeatureGeoUpdate, FeatureFlushDNS},
+		},
+		{
+			name:      "sing-box",
+			version:   map[string]any{"meta": true, "premium": true, "version": "sing-box 1.10.0"},
+			endpoints: []string{"/providers/proxies"},
+			core:      CoreSingBox,
+			has:       []Feature{FeatureProxyProviders, FeatureFlushFakeIP},
+			lacks:     []Feature{FeatureRuleProviders, FeatureGroupDelay, FeatureMemory, FeatureDNSQuery, FeatureRestart, FeatureGeoUpdate, FeatureFlushDNS},
+		},
+		{
+			name:      "clash premium",
+			version:   map[string]any{"premium": true, "version": "2023.08.17"},
+			endpoints: []string{"/providers/proxies", "/providers/rules"},
+			core:      CoreClashPremium,
+			has:       []Feature{FeatureProxyProviders, FeatureRuleProviders},
+			lacks:     []Feature{FeatureGroupDelay, FeatureMemory, FeatureRestart, FeatureFlushFakeIP},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newCoreServer(t, tt.version, tt.endpoints...)
+
+			caps, err := client.ProbeCapabilities(context.Background())
+			if err != nil {
+				t.Fatalf("ProbeCapabilities: %v", err)
+			}
+			if caps.Core != tt.core {
+				t.Errorf("core = %s, want %s", caps.Core, tt.core)
+			}
+			for _, feature := range tt.has {
+				if !caps.Has(feature) {
+					t.Errorf("missing %s", feature)
+				}
+			}
+			for _, feature := range tt.lacks {
+				if caps.Has(feature) {
+					t.Errorf("unexpected %s", feature)
+				}
+			}
+
+			// The probe is cached until the controller changes
+			if cached := client.Capabilities(context.Background()); cached != caps {
+				t.Errorf("cached capabilities = %+v, want %+v", cached, caps)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesAssumeSupportWhenUnreachable(t *testing.T) {
+	client := newCoreServer(t, nil)
+	client.baseURL = "http://127.0.0.1:1"
+
+	caps := client.Capabilities(context.Background())
+	if caps.Probed() {
+		t.Fatal("capabilities of an unreachable core reported as probed")
+	}
+	for feature := range featureCount {
+		if !caps.Has(feature) {
+			t.Errorf("unprobed capabilities lack %s", feature)
+		}
+	}
+}
+
+func TestProbeKeepsCoreMessage(t *testing.T) {
+	server, client := newMockClient(t)
+	server.Fail("GET", "/memory", http.StatusForbidden, "blocked by policy")
+
+	_, err := client.ProbeCapabilities(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "blocked by policy" {
+		t.Errorf("ProbeCapabilities = %v, want the core's message", err)
+	}
+}
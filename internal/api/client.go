@@ -2,83 +2,105 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"sync"
 	"time"
 
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/logging"
 	"mihomoTui/internal/models"
-)
-
-func init() {
-	// output to file
-	logFile, err := os.OpenFile("mihomo.log", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
-	go func() {
-		for {
-			time.Sleep(2 * time.Second)
-			logFile.Sync()
-		}
-	}()
-	if err != nil {
-		log.Fatalf("failed to open log file: %v", err)
-	}
-	log.SetOutput(logFile)
-}
 
-var (
-	Client       *HttpClient
-	StreamClient *HttpClient
+	"github.com/gorilla/websocket"
 )
 
+// slowRequestTimeout bounds requests handled by makeSlowRequest
+const slowRequestTimeout = 2 * time.Minute
+
 // HttpClient represents the API client
 type HttpClient struct {
 	baseURL    string
 	secret     string
 	httpClient *http.Client
+	configErr  error      // Set when the TLS options could not be loaded
+	tunnel     *sshTunnel // Set when the controller is reached over SSH
+
+	// Streaming transport
+	wsDialer      *websocket.Dialer
+	wsUnsupported sync.Map // Endpoint paths whose WebSocket upgrade the controller refused
+
+	// Features of the connected core
+	caps capabilityCache
 }
 
-func InitClient(baseURL, secret string) {
-	Client = &HttpClient{
-		baseURL: baseURL,
-		secret:  secret,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// NewHttpClient creates a client for the controller described by cfg. The
+// address is either an http(s):// URL or a unix:///path/to/socket address,
+// optionally reached through SSH. A zero timeout leaves requests unbounded,
+// as streams need. Close releases the SSH connection.
+func NewHttpClient(cfg config.APIConfig, timeout time.Duration) *HttpClient {
+	return newHttpClient(cfg, newSSHTunnel(cfg.SSH), timeout)
+}
+
+// newHttpClient creates a client that dials through tunnel, if set.
+// Invalid TLS settings fail every request with a KindTLS error rather than
+// refusing to start, so they can still be fixed from the config page.
+func newHttpClient(cfg config.APIConfig, tunnel *sshTunnel, timeout time.Duration) *HttpClient {
+	logging.AddSecret(cfg.Secret)
+	baseURL, socketPath := parseAddress(cfg.BaseURL)
+
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		slog.Error("Failed to load TLS settings", "controller", cfg.BaseURL, "err", err)
 	}
 
-	StreamClient = &HttpClient{
+	dial := controllerDialer(socketPath, tunnel)
+	return &HttpClient{
 		baseURL: baseURL,
-		secret:  secret,
+		secret:  cfg.Secret,
 		httpClient: &http.Client{
-			Timeout: 0, // No timeout for streaming
+			Transport: newTransport(dial, tlsConfig),
+			Timeout:   timeout,
 		},
+		configErr: err,
+		tunnel:    tunnel,
+		wsDialer:  newWebSocketDialer(dial, tlsConfig),
 	}
 }
 
-func UpdateClient(baseURL, secret string) {
-	Client.baseURL = baseURL
-	Client.secret = secret
-
-	StreamClient.baseURL = baseURL
-	StreamClient.secret = secret
+// Close releases idle connections and the SSH connection, if any
+func (c *HttpClient) Close() {
+	if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+	if c.tunnel != nil {
+		c.tunnel.Close()
+	}
+}
 
-	log.Printf("Updated API client: %s, Secret: %s", baseURL, secret)
+// makeRequest makes an HTTP request to the API that is aborted when ctx is cancelled
+func (c *HttpClient) makeRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
+	return c.doRequest(ctx, c.httpClient, method, endpoint, body)
 }
 
-// SetTimeout sets the HTTP client timeout
-func (c *HttpClient) SetTimeout(timeout time.Duration) {
-	c.httpClient.Timeout = timeout
+// makeSlowRequest makes a request the core only answers once the work is
+// done (downloads, health checks), which can outlast the regular timeout
+func (c *HttpClient) makeSlowRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
+	client := *c.httpClient
+	client.Timeout = slowRequestTimeout
+	return c.doRequest(ctx, &client, method, endpoint, body)
 }
 
-// makeRequest makes an HTTP request to the API
-func (c *HttpClient) makeRequest(method, endpoint string, body interface{}) (*http.Response, error) {
+// doRequest builds a request for endpoint and sends it with client
+func (c *HttpClient) doRequest(ctx context.Context, client *http.Client, method, endpoint string, body interface{}) (*http.Response, error) {
+	if c.configErr != nil {
+		return nil, newTransportError(method, endpoint, c.configErr)
+	}
+
 	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-	// Log request details
-	// data, _ := json.MarshalIndent(body, "", "  ")
-	// log.Printf("Request [%s] %s\tData: %s", method, url, string(data))
 
 	var reqBody io.Reader
 	if body != nil {
@@ -89,7 +111,7 @@ func (c *HttpClient) makeRequest(method, endpoint string, body interface{}) (*ht
 		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -100,23 +122,30 @@ func (c *HttpClient) makeRequest(method, endpoint string, body interface{}) (*ht
 		req.Header.Set("Authorization", "Bearer "+c.secret)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	start := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		// Cancellation is the caller's doing, not a controller failure
+		if ctx.Err() == context.Canceled {
+			return nil, ctx.Err()
+		}
+		slog.Debug("Request failed", "method", method, "endpoint", endpoint, "latency", time.Since(start), "err", err)
+		return nil, newTransportError(method, endpoint, err)
 	}
+	slog.Debug("Request", "method", method, "endpoint", endpoint, "status", resp.StatusCode, "latency", time.Since(start))
 
 	return resp, nil
 }
 
-func (c *HttpClient) GetVersion() (*models.Version, error) {
-	resp, err := c.makeRequest("GET", "/version", nil)
+func (c *HttpClient) GetVersion(ctx context.Context) (*models.Version, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/version", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var version models.Version
@@ -128,15 +157,15 @@ func (c *HttpClient) GetVersion() (*models.Version, error) {
 }
 
 // GetConfig retrieves the current configuration
-func (c *HttpClient) GetConfig() (*models.Config, error) {
-	resp, err := c.makeRequest("GET", "/configs", nil)
+func (c *HttpClient) GetConfig(ctx context.Context) (*models.Config, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/configs", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var config models.Config
@@ -147,31 +176,161 @@ func (c *HttpClient) GetConfig() (*models.Config, error) {
 	return &config, nil
 }
 
-// UpdateConfig updates the configuration
-func (c *HttpClient) UpdateConfig(config *models.Config) error {
-	resp, err := c.makeRequest("PATCH", "/configs", config)
+// Ports selects the listener ports changed by SetPorts. Nil fields are
+// left alone; a zero port closes the listener.
+type Ports struct {
+	Port       *int // HTTP proxy
+	SocksPort  *int
+	RedirPort  *int
+	TProxyPort *int
+	MixedPort  *int
+}
+
+// SetMode switches the proxy mode: rule, global or direct
+func (c *HttpClient) SetMode(ctx context.Context, mode string) error {
+	return c.patchConfig(ctx, map[string]any{"mode": mode})
+}
+
+// SetAllowLan sets whether the listeners accept connections from the LAN
+func (c *HttpClient) SetAllowLan(ctx context.Context, allow bool) error {
+	return c.patchConfig(ctx, map[string]any{"allow-lan": allow})
+}
+
+// SetTunEnabled turns the TUN device on or off, keeping its other settings
+func (c *HttpClient) SetTunEnabled(ctx context.Context, enabled bool) error {
+	return c.patchConfig(ctx, map[string]any{"tun": map[string]any{"enable": enabled}})
+}
+
+// SetLogLevel sets the level of the core's log: debug, info, warning,
+// error or silent
+func (c *HttpClient) SetLogLevel(ctx context.Context, level string) error {
+	return c.patchConfig(ctx, map[string]any{"log-level": level})
+}
+
+// SetPorts changes the listener ports set in ports
+func (c *HttpClient) SetPorts(ctx context.Context, ports Ports) error {
+	patch := make(map[string]any)
+	for key, port := range map[string]*int{
+		"port":        ports.Port,
+		"socks-port":  ports.SocksPort,
+		"redir-port":  ports.RedirPort,
+		"tproxy-port": ports.TProxyPort,
+		"mixed-port":  ports.MixedPort,
+	} {
+		if port != nil {
+			patch[key] = *port
+		}
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	return c.patchConfig(ctx, patch)
+}
+
+// patchConfig sends the given /configs keys. The core leaves every other
+// setting as it is, so only the keys being changed may be sent.
+func (c *HttpClient) patchConfig(ctx context.Context, patch map[string]any) error {
+	resp, err := c.makeRequest(ctx, "PATCH", "/configs", patch)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// ReloadConfig makes the core reload its configuration from path.
+// An empty path reloads the file the core was started with.
+func (c *HttpClient) ReloadConfig(ctx context.Context, path string) error {
+	body := map[string]string{"path": path, "payload": ""}
+
+	resp, err := c.makeSlowRequest(ctx, "PUT", "/configs?force=true", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// UpdateGeoData makes the core download fresh GeoIP/GeoSite databases
+func (c *HttpClient) UpdateGeoData(ctx context.Context) error {
+	resp, err := c.makeSlowRequest(ctx, "POST", "/configs/geo", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// Restart restarts the core process
+func (c *HttpClient) Restart(ctx context.Context) error {
+	resp, err := c.makeRequest(ctx, "POST", "/restart", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// FlushFakeIPCache clears the core's fake-ip mapping cache
+func (c *HttpClient) FlushFakeIPCache(ctx context.Context) error {
+	resp, err := c.makeRequest(ctx, "POST", "/cache/fakeip/flush", nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to update config, status: %d", resp.StatusCode)
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// FlushDNSCache clears the core's DNS cache
+func (c *HttpClient) FlushDNSCache(ctx context.Context) error {
+	resp, err := c.makeRequest(ctx, "POST", "/cache/dns/flush", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
 	}
 
 	return nil
 }
 
 // GetProxies retrieves all proxies
-func (c *HttpClient) GetProxies() (map[string]*models.Proxy, error) {
-	resp, err := c.makeRequest("GET", "/proxies", nil)
+func (c *HttpClient) GetProxies(ctx context.Context) (map[string]*models.Proxy, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/proxies", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var result struct {
@@ -186,15 +345,15 @@ func (c *HttpClient) GetProxies() (map[string]*models.Proxy, error) {
 }
 
 // GetProviders retrieves all proxy providers
-func (c *HttpClient) GetProviders() (*models.ProvidersResponse, error) {
-	resp, err := c.makeRequest("GET", "/providers/proxies", nil)
+func (c *HttpClient) GetProviders(ctx context.Context) (*models.ProvidersResponse, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/providers/proxies", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var result models.ProvidersResponse
@@ -205,39 +364,105 @@ func (c *HttpClient) GetProviders() (*models.ProvidersResponse, error) {
 	return &result, nil
 }
 
-// TestGroupDelay tests the delay of all proxies in a group
-func (c *HttpClient) TestGroupDelay(groupName string, testURL string, timeout int) error {
-	endpoint := fmt.Sprintf("/group/%s/delay", groupName)
+// UpdateProxyProvider refreshes a proxy provider from its subscription
+func (c *HttpClient) UpdateProxyProvider(ctx context.Context, name string) error {
+	endpoint := newPath("providers", "proxies", name).String()
+	resp, err := c.makeSlowRequest(ctx, "PUT", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	params := fmt.Sprintf("?url=%s&timeout=%d", testURL, timeout)
-	if testURL == "" {
-		params = fmt.Sprintf("?timeout=%d", timeout)
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
 	}
 
-	resp, err := c.makeRequest("GET", endpoint+params, nil)
+	return nil
+}
+
+// HealthCheckProxyProvider tests the delay of every proxy in a provider
+func (c *HttpClient) HealthCheckProxyProvider(ctx context.Context, name string) error {
+	endpoint := newPath("providers", "proxies", name, "healthcheck").String()
+	resp, err := c.makeSlowRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// GetRuleProviders retrieves all rule providers
+func (c *HttpClient) GetRuleProviders(ctx context.Context) (*models.RuleProvidersResponse, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/providers/rules", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to test group delay, status: %d", resp.StatusCode)
+		return nil, newStatusError(resp)
+	}
+
+	var result models.RuleProvidersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode rule providers: %w", err)
+	}
+
+	return &result, nil
+}
+
+// UpdateRuleProvider refreshes a rule provider from its source
+func (c *HttpClient) UpdateRuleProvider(ctx context.Context, name string) error {
+	endpoint := newPath("providers", "rules", name).String()
+	resp, err := c.makeSlowRequest(ctx, "PUT", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
+
+// TestGroupDelay tests the delay of all proxies in a group
+func (c *HttpClient) TestGroupDelay(ctx context.Context, groupName string, testURL string, timeout int) error {
+	endpoint := newPath("group", groupName, "delay").
+		Param("url", testURL).
+		IntParam("timeout", timeout).
+		String()
+
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp)
 	}
 
 	return nil
 }
 
 // GetProxy retrieves a specific proxy
-func (c *HttpClient) GetProxy(name string) (*models.Proxy, error) {
-	endpoint := fmt.Sprintf("/proxies/%s", name)
-	resp, err := c.makeRequest("GET", endpoint, nil)
+func (c *HttpClient) GetProxy(ctx context.Context, name string) (*models.Proxy, error) {
+	endpoint := newPath("proxies", name).String()
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var proxy models.Proxy
@@ -249,40 +474,38 @@ func (c *HttpClient) GetProxy(name string) (*models.Proxy, error) {
 }
 
 // SelectProxy selects a proxy for a group
-func (c *HttpClient) SelectProxy(groupName, proxyName string) error {
-	endpoint := fmt.Sprintf("/proxies/%s", groupName)
+func (c *HttpClient) SelectProxy(ctx context.Context, groupName, proxyName string) error {
+	endpoint := newPath("proxies", groupName).String()
 	body := map[string]string{"name": proxyName}
 
-	resp, err := c.makeRequest("PUT", endpoint, body)
+	resp, err := c.makeRequest(ctx, "PUT", endpoint, body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to select proxy, status: %d", resp.StatusCode)
+		return newStatusError(resp)
 	}
 
 	return nil
 }
 
 // TestProxyDelay tests the delay of a proxy
-func (c *HttpClient) TestProxyDelay(name string, testURL string, timeout int) (int, error) {
-	endpoint := fmt.Sprintf("/proxies/%s/delay", name)
-
-	params := fmt.Sprintf("?url=%s&timeout=%d", testURL, timeout)
-	if testURL == "" {
-		params = fmt.Sprintf("?timeout=%d", timeout)
-	}
+func (c *HttpClient) TestProxyDelay(ctx context.Context, name string, testURL string, timeout int) (int, error) {
+	endpoint := newPath("proxies", name, "delay").
+		Param("url", testURL).
+		IntParam("timeout", timeout).
+		String()
 
-	resp, err := c.makeRequest("GET", endpoint+params, nil)
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("failed to test delay, status: %d", resp.StatusCode)
+		return 0, newStatusError(resp)
 	}
 
 	var result struct {
@@ -297,15 +520,15 @@ func (c *HttpClient) TestProxyDelay(name string, testURL string, timeout int) (i
 }
 
 // GetRules retrieves all rules
-func (c *HttpClient) GetRules() ([]models.Rule, error) {
-	resp, err := c.makeRequest("GET", "/rules", nil)
+func (c *HttpClient) GetRules(ctx context.Context) ([]models.Rule, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/rules", nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	var result struct {
@@ -320,54 +543,87 @@ func (c *HttpClient) GetRules() ([]models.Rule, error) {
 }
 
 // GetConnections retrieves all active connections
-func (c *HttpClient) GetConnections() ([]models.Connection, error) {
-	resp, err := c.makeRequest("GET", "/connections", nil)
+func (c *HttpClient) GetConnections(ctx context.Context) ([]models.Connection, error) {
+	snapshot, err := c.GetConnectionsSnapshot(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	return snapshot.Connections, nil
+}
+
+// GetConnectionsSnapshot retrieves the active connections along with the
+// traffic totals and memory usage reported next to them
+func (c *HttpClient) GetConnectionsSnapshot(ctx context.Context) (*models.ConnectionsSnapshot, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/connections", nil)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	var result struct {
-		Connections []models.Connection `json:"connections"`
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var snapshot models.ConnectionsSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
 		return nil, fmt.Errorf("failed to decode connections: %w", err)
 	}
 
-	return result.Connections, nil
+	return &snapshot, nil
 }
 
 // CloseConnection closes a specific connection
-func (c *HttpClient) CloseConnection(id string) error {
-	endpoint := fmt.Sprintf("/connections/%s", id)
-	resp, err := c.makeRequest("DELETE", endpoint, nil)
+func (c *HttpClient) CloseConnection(ctx context.Context, id string) error {
+	endpoint := newPath("connections", id).String()
+	resp, err := c.makeRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to close connection, status: %d", resp.StatusCode)
+		return newStatusError(resp)
 	}
 
 	return nil
 }
 
+// QueryDNS resolves name through the core's DNS resolver
+func (c *HttpClient) QueryDNS(ctx context.Context, name, queryType string) (*models.DNSQueryResult, error) {
+	endpoint := newPath("dns", "query").
+		Param("name", name).
+		Param("type", queryType).
+		String()
+
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	var result models.DNSQueryResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode DNS result: %w", err)
+	}
+
+	return &result, nil
+}
+
 // HealthCheck checks if the API is accessible
-func (c *HttpClient) HealthCheck() error {
-	resp, err := c.makeRequest("GET", "/", nil)
+func (c *HttpClient) HealthCheck(ctx context.Context) error {
+	resp, err := c.makeRequest(ctx, "GET", "/", nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("health check failed, status: %d", resp.StatusCode)
+		return newStatusError(resp)
 	}
 
 	return nil
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/api/apitest"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+// newMockClient starts a fake controller and a client pointed at it
+func newMockClient(t *testing.T) (*apitest.Server, *HttpClient) {
+	t.Helper()
+	server := apitest.NewServer()
+	t.Cleanup(server.Close)
+
+	client := NewHttpClient(config.APIConfig{BaseURL: server.URL}, 5*time.Second)
+	t.Cleanup(client.Close)
+	return server, client
+}
+
+func TestClientReadsState(t *testing.T) {
+	_, client := newMockClient(t)
+	ctx := context.Background()
+
+	if err := client.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+
+	version, err := client.GetVersion(ctx)
+	if err != nil || !version.Meta || version.Version != "v1.19.0" {
+		t.Errorf("GetVersion = %+v, %v", version, err)
+	}
+
+	cfg, err := client.GetConfig(ctx)
+	if err != nil || cfg.Mode != "rule" || cfg.MixedPort != 7893 {
+		t.Errorf("GetConfig = %+v, %v", cfg, err)
+	}
+
+	proxies, err := client.GetProxies(ctx)
+	if err != nil || len(proxies) != 8 {
+		t.Errorf("GetProxies returned %d proxies, %v", len(proxies), err)
+	}
+
+	// Names with '/' and '#' must survive the round trip
+	proxy, err := client.GetProxy(ctx, "US/LA #3")
+	if err != nil || proxy.Name != "US/LA #3" {
+		t.Errorf("GetProxy = %+v, %v", proxy, err)
+	}
+
+	providers, err := client.GetProviders(ctx)
+	if err != nil || len(providers.Providers["subscription"].Proxies) != 3 {
+		t.Errorf("GetProviders = %+v, %v", providers, err)
+	}
+
+	ruleProviders, err := client.GetRuleProviders(ctx)
+	if err != nil || ruleProviders.Providers["reject"].RuleCount != 120 {
+		t.Errorf("GetRuleProviders = %+v, %v", ruleProviders, err)
+	}
+
+	rules, err := client.GetRules(ctx)
+	if err != nil || len(rules) != 4 || rules[3].Type != "Match" {
+		t.Errorf("GetRules = %+v, %v", rules, err)
+	}
+
+	snapshot, err := client.GetConnectionsSnapshot(ctx)
+	if err != nil || len(snapshot.Connections) != 2 || snapshot.Memory != 48<<20 {
+		t.Errorf("GetConnectionsSnapshot = %+v, %v", snapshot, err)
+	}
+
+	result, err := client.QueryDNS(ctx, "example.com", "A")
+	if err != nil || len(result.Answer) != 1 || result.Answer[0].Data != "93.184.215.14" {
+		t.Errorf("QueryDNS = %+v, %v", result, err)
+	}
+}
+
+func TestSelectProxy(t *testing.T) {
+	server, client := newMockClient(t)
+	ctx := context.Background()
+
+	if err := client.SelectProxy(ctx, "🚀 节点选择", "US/LA #3"); err != nil {
+		t.Fatalf("SelectProxy: %v", err)
+	}
+	server.View(func(state *apitest.State) {
+		if now := state.Proxies["🚀 节点选择"].Now; now != "US/LA #3" {
+			t.Errorf("selected %q, want US/LA #3", now)
+		}
+	})
+
+	err := client.SelectProxy(ctx, "🚀 节点选择", "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != KindBadRequest || apiErr.Message == "" {
+		t.Errorf("selecting an unknown proxy = %v, want bad request with the core's message", err)
+	}
+
+	if err := client.SelectProxy(ctx, "missing", "DIRECT"); !IsKind(err, KindNotFound) {
+		t.Errorf("selecting in an unknown group = %v, want not found", err)
+	}
+}
+
+func TestDelayTests(t *testing.T) {
+	server, client := newMockClient(t)
+	ctx := context.Background()
+	testURL := "https://www.gstatic.com/generate_204"
+
+	delay, err := client.TestProxyDelay(ctx, "🇭🇰 香港 01", testURL, 5000)
+	if err != nil || delay != 86 {
+		t.Errorf("TestProxyDelay = %d, %v, want 86", delay, err)
+	}
+
+	// Groups report the delay of the selected node
+	delay, err = client.TestProxyDelay(ctx, "♻️ 自动选择", testURL, 5000)
+	if err != nil || delay != 86 {
+		t.Errorf("TestProxyDelay of a group = %d, %v, want 86", delay, err)
+	}
+
+	if _, err := client.TestProxyDelay(ctx, "US/LA #3", testURL, 5000); !IsKind(err, KindTimeout) {
+		t.Errorf("TestProxyDelay of a dead node = %v, want timeout", err)
+	}
+
+	if err := client.TestGroupDelay(ctx, "♻️ 自动选择", testURL, 5000); err != nil {
+		t.Errorf("TestGroupDelay: %v", err)
+	}
+	server.View(func(state *apitest.State) {
+		if history := state.Proxies["🇯🇵 日本 02"].History; len(history) != 1 || history[0].Delay != 142 {
+			t.Errorf("history after group test = %+v", history)
+		}
+	})
+}
+
+func TestConfigPatches(t *testing.T) {
+	server, client := newMockClient(t)
+	ctx := context.Background()
+	mixedPort := 7899
+
+	patches := []struct {
+		name string
+		run  func() error
+		want string // Exact body sent
+	}{
+		{"mode", func() error { return client.SetMode(ctx, "global") }, `{"mode":"global"}`},
+		{"allow-lan", func() error { return client.SetAllowLan(ctx, true) }, `{"allow-lan":true}`},
+		{"tun", func() error { return client.SetTunEnabled(ctx, true) }, `{"tun":{"enable":true}}`},
+		{"log-level", func() error { return client.SetLogLevel(ctx, "debug") }, `{"log-level":"debug"}`},
+		{"ports", func() error { return client.SetPorts(ctx, Ports{MixedPort: &mixedPort}) }, `{"mixed-port":7899}`},
+	}
+	for _, patch := range patches {
+		if err := patch.run(); err != nil {
+			t.Errorf("%s: %v", patch.name, err)
+			continue
+		}
+		request, ok := server.LastRequest("PATCH", "/configs")
+		if !ok {
+			t.Fatalf("%s: no PATCH /configs request", patch.name)
+		}
+		if body := strings.TrimSpace(string(request.Body)); body != patch.want {
+			t.Errorf("%s sent %s, want %s", patch.name, body, patch.want)
+		}
+	}
+
+	// Everything not patched is left as the core had it
+	server.View(func(state *apitest.State) {
+		cfg := state.Config
+		if cfg.Mode != "global" || !cfg.AllowLan || cfg.LogLevel != "debug" || cfg.MixedPort != 7899 {
+			t.Errorf("patched config = %+v", cfg)
+		}
+		if cfg.Port != 7890 || cfg.SocksPort != 7891 {
+			t.Errorf("untouched ports changed: %+v", cfg)
+		}
+		if !cfg.Tun.Enable || cfg.Tun.Stack != "mixed" || cfg.Tun.MTU != 9000 || len(cfg.Tun.DNSHijack) != 1 {
+			t.Errorf("tun = %+v, want enabled with the rest kept", cfg.Tun)
+		}
+	})
+
+	requests := len(server.Requests())
+	if err := client.SetPorts(ctx, Ports{}); err != nil || len(server.Requests()) != requests {
+		t.Errorf("SetPorts without ports = %v, sent %d requests", err, len(server.Requests())-requests)
+	}
+}
+
+func TestActions(t *testing.T) {
+	server, client := newMockClient(t)
+	ctx := context.Background()
+
+	actions := []struct {
+		method, path string
+		run          func() error
+	}{
+		{"PUT", "/configs", func() error { return client.ReloadConfig(ctx, "") }},
+		{"POST", "/configs/geo", func() error { return client.UpdateGeoData(ctx) }},
+		{"POST", "/restart", func() error { return client.Restart(ctx) }},
+		{"POST", "/cache/fakeip/flush", func() error { return client.FlushFakeIPCache(ctx) }},
+		{"POST", "/cache/dns/flush", func() error { return client.FlushDNSCache(ctx) }},
+		{"PUT", "/providers/proxies/subscription", func() error { return client.UpdateProxyProvider(ctx, "subscription") }},
+		{"GET", "/providers/proxies/subscription/healthcheck", func() error { return client.HealthCheckProxyProvider(ctx, "subscription") }},
+		{"PUT", "/providers/rules/reject", func() error { return client.UpdateRuleProvider(ctx, "reject") }},
+		{"DELETE", "/connections/1b7e3c9a-0001", func() error { return client.CloseConnection(ctx, "1b7e3c9a-0001") }},
+	}
+	for _, action := range actions {
+		if err := action.run(); err != nil {
+			t.Errorf("%s %s: %v", action.method, action.path, err)
+		}
+		if _, ok := server.LastRequest(action.method, action.path); !ok {
+			t.Errorf("%s %s was not sent", action.method, action.path)
+		}
+	}
+
+	server.View(func(state *apitest.State) {
+		if len(state.Connections) != 1 {
+			t.Errorf("%d connections left, want 1", len(state.Connections))
+		}
+	})
+}
+
+func TestErrors(t *testing.T) {
+	server, client := newMockClient(t)
+	ctx := context.Background()
+
+	server.Fail("GET", "/rules", http.StatusInternalServerError, "rules unavailable")
+	_, err := client.GetRules(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != KindServer || apiErr.Message != "rules unavailable" {
+		t.Errorf("GetRules = %v, want server error with the core's message", err)
+	}
+	server.ClearFailures()
+	if _, err := client.GetRules(ctx); err != nil {
+		t.Errorf("GetRules after clearing failures: %v", err)
+	}
+
+	server.Disable("/providers/rules")
+	if _, err := client.GetRuleProviders(ctx); !IsKind(err, KindNotFound) {
+		t.Errorf("GetRuleProviders on a core without them = %v, want not found", err)
+	}
+
+	server.SetSecret("s3cret")
+	if _, err := client.GetVersion(ctx); !IsKind(err, KindUnauthorized) {
+		t.Errorf("GetVersion without the secret = %v, want unauthorized", err)
+	}
+	authorized := NewHttpClient(config.APIConfig{BaseURL: server.URL, Secret: "s3cret"}, 5*time.Second)
+	defer authorized.Close()
+	if _, err := authorized.GetVersion(ctx); err != nil {
+		t.Errorf("GetVersion with the secret: %v", err)
+	}
+}
+
+// streamTransports runs a test against a core that accepts WebSocket and
+// against one behind a proxy that only passes plain HTTP
+func streamTransports(t *testing.T, test func(t *testing.T, server *apitest.Server, client *HttpClient)) {
+	for _, transport := range []string{"websocket", "chunked"} {
+		t.Run(transport, func(t *testing.T) {
+			server, client := newMockClient(t)
+			server.SetSecret("s3cret")
+			if transport == "chunked" {
+				server.DisableWebSocket()
+			}
+			client = NewHttpClient(config.APIConfig{BaseURL: server.URL, Secret: "s3cret"}, 0)
+			t.Cleanup(client.Close)
+
+			test(t, server, client)
+		})
+	}
+}
+
+// receive waits for the next value on ch
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream message")
+		panic("unreachable")
+	}
+}
+
+func TestStreams(t *testing.T) {
+	streamTransports(t, func(t *testing.T, server *apitest.Server, client *HttpClient) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		traffic := make(chan *models.Traffic, 1)
+		logs := make(chan *models.Log, 1)
+		memory := make(chan *models.MemoryUsage, 1)
+		done := make(chan error, 3)
+		go func() { done <- client.StreamTraffic(ctx, func(m *models.Traffic) { traffic <- m }) }()
+		go func() { done <- client.StreamLogs(ctx, func(m *models.Log) { logs <- m }) }()
+		go func() { done <- client.StreamMemoryUsage(ctx, func(m *models.MemoryUsage) { memory <- m }) }()
+
+		for _, stream := range []string{apitest.StreamTraffic, apitest.StreamLogs, apitest.StreamMemory} {
+			if !server.WaitSubscribers(stream, 1, 5*time.Second) {
+				t.Fatalf("client never subscribed to %s", stream)
+			}
+		}
+
+		server.PublishTraffic(1024, 4096)
+		server.PublishLog("warning", "dial timeout")
+		server.PublishMemory(64 << 20)
+
+		if got := receive(t, traffic); got.Up != 1024 || got.Down != 4096 {
+			t.Errorf("traffic = %+v", got)
+		}
+		if got := receive(t, logs); got.Type != "warning" || got.Payload != "dial timeout" {
+			t.Errorf("log = %+v", got)
+		}
+		if got := receive(t, memory); got.Inuse != 64<<20 {
+			t.Errorf("memory = %+v", got)
+		}
+
+		cancel()
+		for range 3 {
+			if err := receive(t, done); !errors.Is(err, context.Canceled) {
+				t.Errorf("stream ended with %v, want context.Canceled", err)
+			}
+		}
+	})
+}
+
+func TestWatchConnections(t *testing.T) {
+	streamTransports(t, func(t *testing.T, server *apitest.Server, client *HttpClient) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		diffs := make(chan *models.ConnectionsDiff, 16)
+		go client.WatchConnections(ctx, 20*time.Millisecond, func(diff *models.ConnectionsDiff) {
+			diffs <- diff
+		})
+
+		first := receive(t, diffs)
+		if len(first.Connections) != 2 || len(first.Added) != 2 {
+			t.Fatalf("first diff = %+v, want both connections added", first)
+		}
+
+		server.Update(func(state *apitest.State) {
+			state.Connections = state.Connections[1:]
+		})
+		for {
+			diff := receive(t, diffs)
+			if len(diff.Closed) == 0 {
+				continue
+			}
+			if diff.Closed[0].ID != "1b7e3c9a-0001" || len(diff.Connections) != 1 {
+				t.Errorf("diff after closing = %+v", diff)
+			}
+			break
+		}
+	})
+}
+
+func TestMockServesSampleJSON(t *testing.T) {
+	server, _ := newMockClient(t)
+
+	// The sample config must look like a real mihomo /configs answer
+	resp, err := http.Get(server.URL + "/configs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var raw map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"mode", "mixed-port", "tun", "log-level"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("/configs lacks %q", key)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"mihomoTui/internal/models"
+)
+
+// WatchConnections subscribes to the connections stream and reports each
+// snapshot as a diff against the previous one, with per-connection
+// upload/download rates computed from the byte counters.
+func (c *HttpClient) WatchConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsDiff)) error {
+	tracker := newConnectionsTracker()
+	return c.StreamConnections(ctx, interval, func(snapshot *models.ConnectionsSnapshot) {
+		callback(tracker.update(snapshot, time.Now()))
+	})
+}
+
+// connectionsTracker remembers the previous snapshot to diff against
+type connectionsTracker struct {
+	order    []string
+	previous map[string]models.Connection
+	lastSeen time.Time
+
+	lastUploadTotal   int64
+	lastDownloadTotal int64
+}
+
+// newConnectionsTracker creates an empty tracker
+func newConnectionsTracker() *connectionsTracker {
+	return &connectionsTracker{
+		previous: make(map[string]models.Connection),
+	}
+}
+
+// update diffs snapshot against the previous one taken at now.
+// Connections keep their previous position; new ones are appended so
+// tables built from the result don't reshuffle on every tick.
+func (t *connectionsTracker) update(snapshot *models.ConnectionsSnapshot, now time.Time) *models.ConnectionsDiff {
+	elapsed := now.Sub(t.lastSeen).Seconds()
+	first := t.lastSeen.IsZero()
+
+	current := make(map[string]models.Connection, len(snapshot.Connections))
+	for _, conn := range snapshot.Connections {
+		current[conn.ID] = conn
+	}
+
+	diff := &models.ConnectionsDiff{
+		Connections:   make([]models.Connection, 0, len(snapshot.Connections)),
+		UploadTotal:   snapshot.UploadTotal,
+		DownloadTotal: snapshot.DownloadTotal,
+	}
+
+	order := make([]string, 0, len(snapshot.Connections))
+
+	// Existing connections, in their previous order
+	for _, id := range t.order {
+		prev := t.previous[id]
+		conn, ok := current[id]
+		if !ok {
+			diff.Closed = append(diff.Closed, prev)
+			continue
+		}
+
+		if elapsed > 0 {
+			conn.UploadSpeed = rate(conn.Upload-prev.Upload, elapsed)
+			conn.DownloadSpeed = rate(conn.Download-prev.Download, elapsed)
+		}
+		diff.Connections = append(diff.Connections, conn)
+		order = append(order, id)
+		delete(current, id)
+	}
+
+	// New connections, in snapshot order
+	for _, conn := range snapshot.Connections {
+		if _, ok := current[conn.ID]; !ok {
+			continue
+		}
+
+		// Everything a connection transferred since the last tick is new
+		if !first && elapsed > 0 {
+			conn.UploadSpeed = rate(conn.Upload, elapsed)
+			conn.DownloadSpeed = rate(conn.Download, elapsed)
+		}
+		diff.Connections = append(diff.Connections, conn)
+		diff.Added = append(diff.Added, conn)
+		order = append(order, conn.ID)
+	}
+
+	if !first && elapsed > 0 {
+		diff.UploadSpeed = rate(snapshot.UploadTotal-t.lastUploadTotal, elapsed)
+		diff.DownloadSpeed = rate(snapshot.DownloadTotal-t.lastDownloadTotal, elapsed)
+	}
+
+	// Remember this snapshot for the next tick
+	t.order = order
+	t.previous = make(map[string]models.Connection, len(diff.Connections))
+	for _, conn := range diff.Connections {
+		t.previous[conn.ID] = conn
+	}
+	t.lastSeen = now
+	t.lastUploadTotal = snapshot.UploadTotal
+	t.lastDownloadTotal = snapshot.DownloadTotal
+
+	return diff
+}
+
+// rate converts a byte delta over seconds into bytes per second.
+// Counters reset when the core restarts, so negative deltas count as zero.
+func rate(delta int64, seconds float64) int64 {
+	if delta <= 0 {
+		return 0
+	}
+	return int64(float64(delta) / seconds)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/models"
+)
+
+// snapshot builds a connections snapshot holding conns
+func snapshot(uploadTotal, downloadTotal int64, conns ...models.Connection) *models.ConnectionsSnapshot {
+	return &models.ConnectionsSnapshot{UploadTotal: uploadTotal, DownloadTotal: downloadTotal, Connections: conns}
+}
+
+// conn builds a connection with the given byte counters
+func conn(id string, upload, download int64) models.Connection {
+	return models.Connection{ID: id, Upload: upload, Download: download}
+}
+
+// ids lists the IDs of conns in order
+func ids(conns []models.Connection) []string {
+	result := make([]string, len(conns))
+	for i, c := range conns {
+		result[i] = c.ID
+	}
+	return result
+}
+
+func TestConnectionsTracker(t *testing.T) {
+	tracker := newConnectionsTracker()
+	start := time.Unix(1700000000, 0)
+
+	// The first snapshot has nothing to compare against
+	diff := tracker.update(snapshot(1000, 2000, conn("a", 100, 200), conn("b", 300, 400)), start)
+	if got := ids(diff.Added); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("first snapshot added %v, want [a b]", got)
+	}
+	if len(diff.Closed) != 0 || diff.UploadSpeed != 0 || diff.DownloadSpeed != 0 {
+		t.Errorf("first snapshot = %+v, want no closed connections or rates", diff)
+	}
+	for _, c := range diff.Connections {
+		if c.UploadSpeed != 0 || c.DownloadSpeed != 0 {
+			t.Errorf("first snapshot gave %s rates %d/%d", c.ID, c.UploadSpeed, c.DownloadSpeed)
+		}
+	}
+
+	// Two seconds later: a closed, c opened, b kept its position
+	diff = tracker.update(snapshot(3000, 6000, conn("c", 50, 80), conn("b", 700, 1200)), start.Add(2*time.Second))
+	if got := ids(diff.Connections); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("connections = %v, want [b c]", got)
+	}
+	if got := ids(diff.Added); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("added = %v, want [c]", got)
+	}
+	if got := ids(diff.Closed); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("closed = %v, want [a]", got)
+	}
+	if diff.UploadSpeed != 1000 || diff.DownloadSpeed != 2000 {
+		t.Errorf("total rates = %d/%d, want 1000/2000", diff.UploadSpeed, diff.DownloadSpeed)
+	}
+
+	want := map[string][2]int64{
+		"b": {200, 400}, // Delta since the previous snapshot
+		"c": {25, 40},   // Everything a new connection sent is new
+	}
+	for _, c := range diff.Connections {
+		if got := [2]int64{c.UploadSpeed, c.DownloadSpeed}; got != want[c.ID] {
+			t.Errorf("%s rates = %v, want %v", c.ID, got, want[c.ID])
+		}
+	}
+}
+
+func TestConnectionsTrackerCounterReset(t *testing.T) {
+	tracker := newConnectionsTracker()
+	start := time.Unix(1700000000, 0)
+	tracker.update(snapshot(5000, 9000, conn("a", 4000, 8000)), start)
+
+	// The core restarted and reuses the ID with fresh counters
+	diff := tracker.update(snapshot(10, 20, conn("a", 10, 20)), start.Add(time.Second))
+	if diff.UploadSpeed != 0 || diff.DownloadSpeed != 0 {
+		t.Errorf("total rates after a reset = %d/%d, want 0/0", diff.UploadSpeed, diff.DownloadSpeed)
+	}
+	if c := diff.Connections[0]; c.UploadSpeed != 0 || c.DownloadSpeed != 0 {
+		t.Errorf("connection rates after a reset = %d/%d, want 0/0", c.UploadSpeed, c.DownloadSpeed)
+	}
+
+	// Rates resume from the new counters
+	diff = tracker.update(snapshot(110, 220, conn("a", 110, 220)), start.Add(2*time.Second))
+	if c := diff.Connections[0]; c.UploadSpeed != 100 || c.DownloadSpeed != 200 {
+		t.Errorf("connection rates = %d/%d, want 100/200", c.UploadSpeed, c.DownloadSpeed)
+	}
+}
+
+func TestConnectionsTrackerSameInstant(t *testing.T) {
+	tracker := newConnectionsTracker()
+	now := time.Unix(1700000000, 0)
+	tracker.update(snapshot(0, 0, conn("a", 0, 0)), now)
+
+	// No time passed, so no rate can be computed
+	diff := tracker.update(snapshot(100, 100, conn("a", 100, 100), conn("b", 5, 5)), now)
+	for _, c := range diff.Connections {
+		if c.UploadSpeed != 0 || c.DownloadSpeed != 0 {
+			t.Errorf("%s rates = %d/%d, want 0/0", c.ID, c.UploadSpeed, c.DownloadSpeed)
+		}
+	}
+}
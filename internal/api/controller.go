@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+// Controller is the controller API the UI works with. HttpClient talks to
+// a real core; tests can pass any other implementation.
+type Controller interface {
+	HealthCheck(ctx context.Context) error
+	GetVersion(ctx context.Context) (*models.Version, error)
+	Capabilities(ctx context.Context) Capabilities
+	ProbeCapabilities(ctx context.Context) (Capabilities, error)
+
+	GetConfig(ctx context.Context) (*models.Config, error)
+	SetMode(ctx context.Context, mode string) error
+	SetAllowLan(ctx context.Context, allow bool) error
+	SetTunEnabled(ctx context.Context, enabled bool) error
+	SetLogLevel(ctx context.Context, level string) error
+	SetPorts(ctx context.Context, ports Ports) error
+	ReloadConfig(ctx context.Context, path string) error
+	UpdateGeoData(ctx context.Context) error
+	Restart(ctx context.Context) error
+	FlushFakeIPCache(ctx context.Context) error
+	FlushDNSCache(ctx context.Context) error
+
+	GetProxies(ctx context.Context) (map[string]*models.Proxy, error)
+	GetProxy(ctx context.Context, name string) (*models.Proxy, error)
+	SelectProxy(ctx context.Context, groupName, proxyName string) error
+	TestProxyDelay(ctx context.Context, name string, testURL string, timeout int) (int, error)
+	TestGroupDelay(ctx context.Context, groupName string, testURL string, timeout int) error
+
+	GetProviders(ctx context.Context) (*models.ProvidersResponse, error)
+	UpdateProxyProvider(ctx context.Context, name string) error
+	HealthCheckProxyProvider(ctx context.Context, name string) error
+	GetRuleProviders(ctx context.Context) (*models.RuleProvidersResponse, error)
+	UpdateRuleProvider(ctx context.Context, name string) error
+	GetRules(ctx context.Context) ([]models.Rule, error)
+
+	GetConnections(ctx context.Context) ([]models.Connection, error)
+	GetConnectionsSnapshot(ctx context.Context) (*models.ConnectionsSnapshot, error)
+	CloseConnection(ctx context.Context, id string) error
+	QueryDNS(ctx context.Context, name, queryType string) (*models.DNSQueryResult, error)
+
+	// Streams block until ctx is cancelled or the connection drops
+	StreamTraffic(ctx context.Context, callback func(*models.Traffic)) error
+	StreamLogs(ctx context.Context, callback func(*models.Log)) error
+	StreamMemoryUsage(ctx context.Context, callback func(*models.MemoryUsage)) error
+	StreamConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsSnapshot)) error
+	WatchConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsDiff)) error
+}
+
+var (
+	_ Controller = (*HttpClient)(nil)
+	_ Controller = (*ActiveController)(nil)
+)
+
+// requestTimeout bounds regular requests to the active controller
+const requestTimeout = 10 * time.Second
+
+// clientPair reaches one controller: requests are bounded by a timeout,
+// streams are not. Both share one SSH connection.
+type clientPair struct {
+	requests *HttpClient
+	streams  *HttpClient
+	tunnel   *sshTunnel
+}
+
+// newClientPair creates the clients for cfg
+func newClientPair(cfg config.APIConfig) *clientPair {
+	tunnel := newSSHTunnel(cfg.SSH)
+	return &clientPair{
+		requests: newHttpClient(cfg, tunnel, requestTimeout),
+		streams:  newHttpClient(cfg, tunnel, 0),
+		tunnel:   tunnel,
+	}
+}
+
+// close releases idle connections and the SSH connection
+func (p *clientPair) close() {
+	p.requests.Close()
+	p.streams.Close()
+}
+
+// ActiveController is the Controller for the active profile. Switch points
+// it at another core; calls already running finish against the old one,
+// and every call made afterwards goes to the new one.
+type ActiveController struct {
+	current atomic.Pointer[clientPair]
+}
+
+// NewActiveController creates a controller for cfg
+func NewActiveController(cfg config.APIConfig) *ActiveController {
+	c := &ActiveController{}
+	c.current.Store(newClientPair(cfg))
+	return c
+}
+
+// Switch moves to the controller described by cfg and makes supervised
+// streams reconnect to it
+func (c *ActiveController) Switch(cfg config.APIConfig) {
+	old := c.current.Swap(newClientPair(cfg))
+	notifyConfigChanged()
+	old.close()
+
+	slog.Info("Switched controller", "controller", cfg.BaseURL)
+}
+
+// Close releases the connections to the current controller
+func (c *ActiveController) Close() {
+	c.current.Load().close()
+}
+
+// client returns the client for regular requests
+func (c *ActiveController) client() *HttpClient {
+	return c.current.Load().requests
+}
+
+// streamClient returns the client for streams
+func (c *ActiveController) streamClient() *HttpClient {
+	return c.current.Load().streams
+}
+
+func (c *ActiveController) HealthCheck(ctx context.Context) error {
+	return c.client().HealthCheck(ctx)
+}
+
+func (c *ActiveController) GetVersion(ctx context.Context) (*models.Version, error) {
+	return c.client().GetVersion(ctx)
+}
+
+func (c *ActiveController) Capabilities(ctx context.Context) Capabilities {
+	return c.client().Capabilities(ctx)
+}
+
+func (c *ActiveController) ProbeCapabilities(ctx context.Context) (Capabilities, error) {
+	return c.client().ProbeCapabilities(ctx)
+}
+
+func (c *ActiveController) GetConfig(ctx context.Context) (*models.Config, error) {
+	return c.client().GetConfig(ctx)
+}
+
+func (c *ActiveController) SetMode(ctx context.Context, mode string) error {
+	return c.client().SetMode(ctx, mode)
+}
+
+func (c *ActiveController) SetAllowLan(ctx context.Context, allow bool) error {
+	return c.client().SetAllowLan(ctx, allow)
+}
+
+func (c *ActiveController) SetTunEnabled(ctx context.Context, enabled bool) error {
+	return c.client().SetTunEnabled(ctx, enabled)
+}
+
+func (c *ActiveController) SetLogLevel(ctx context.Context, level string) error {
+	return c.client().SetLogLevel(ctx, level)
+}
+
+func (c *ActiveController) SetPorts(ctx context.Context, ports Ports) error {
+	return c.client().SetPorts(ctx, ports)
+}
+
+func (c *ActiveController) ReloadConfig(ctx context.Context, path string) error {
+	return c.client().ReloadConfig(ctx, path)
+}
+
+func (c *ActiveController) UpdateGeoData(ctx context.Context) error {
+	return c.client().UpdateGeoData(ctx)
+}
+
+func (c *ActiveController) Restart(ctx context.Context) error {
+	return c.client().Restart(ctx)
+}
+
+func (c *ActiveController) FlushFakeIPCache(ctx context.Context) error {
+	return c.client().FlushFakeIPCache(ctx)
+}
+
+func (c *ActiveController) FlushDNSCache(ctx context.Context) error {
+	return c.client().FlushDNSCache(ctx)
+}
+
+func (c *ActiveController) GetProxies(ctx context.Context) (map[string]*models.Proxy, error) {
+	return c.client().GetProxies(ctx)
+}
+
+func (c *ActiveController) GetProxy(ctx context.Context, name string) (*models.Proxy, error) {
+	return c.client().GetProxy(ctx, name)
+}
+
+func (c *ActiveController) SelectProxy(ctx context.Context, groupName, proxyName string) error {
+	return c.client().SelectProxy(ctx, groupName, proxyName)
+}
+
+func (c *ActiveController) TestProxyDelay(ctx context.Context, name string, testURL string, timeout int) (int, error) {
+	return c.client().TestProxyDelay(ctx, name, testURL, timeout)
+}
+
+func (c *ActiveController) TestGroupDelay(ctx context.Context, groupName string, testURL string, timeout int) error {
+	return c.client().TestGroupDelay(ctx, groupName, testURL, timeout)
+}
+
+func (c *ActiveController) GetProviders(ctx context.Context) (*models.ProvidersResponse, error) {
+	return c.client().GetProviders(ctx)
+}
+
+func (c *ActiveController) UpdateProxyProvider(ctx context.Context, name string) error {
+	return c.client().UpdateProxyProvider(ctx, name)
+}
+
+func (c *ActiveController) HealthCheckProxyProvider(ctx context.Context, name string) error {
+	return c.client().HealthCheckProxyProvider(ctx, name)
+}
+
+func (c *ActiveController) GetRuleProviders(ctx context.Context) (*models.RuleProvidersResponse, error) {
+	return c.client().GetRuleProviders(ctx)
+}
+
+func (c *ActiveController) UpdateRuleProvider(ctx context.Context, name string) error {
+	return c.client().UpdateRuleProvider(ctx, name)
+}
+
+func (c *ActiveController) GetRules(ctx context.Context) ([]models.Rule, error) {
+	return c.client().GetRules(ctx)
+}
+
+func (c *ActiveController) GetConnections(ctx context.Context) ([]models.Connection, error) {
+	return c.client().GetConnections(ctx)
+}
+
+func (c *ActiveController) GetConnectionsSnapshot(ctx context.Context) (*models.ConnectionsSnapshot, error) {
+	return c.client().GetConnectionsSnapshot(ctx)
+}
+
+func (c *ActiveController) CloseConnection(ctx context.Context, id string) error {
+	return c.client().CloseConnection(ctx, id)
+}
+
+func (c *ActiveController) QueryDNS(ctx context.Context, name, queryType string) (*models.DNSQueryResult, error) {
+	return c.client().QueryDNS(ctx, name, queryType)
+}
+
+func (c *ActiveController) StreamTraffic(ctx context.Context, callback func(*models.Traffic)) error {
+	return c.streamClient().StreamTraffic(ctx, callback)
+}
+
+func (c *ActiveController) StreamLogs(ctx context.Context, callback func(*models.Log)) error {
+	return c.streamClient().StreamLogs(ctx, callback)
+}
+
+func (c *ActiveController) StreamMemoryUsage(ctx context.Context, callback func(*models.MemoryUsage)) error {
+	return c.streamClient().StreamMemoryUsage(ctx, callback)
+}
+
+func (c *ActiveController) StreamConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsSnapshot)) error {
+	return c.streamClient().StreamConnections(ctx, interval, callback)
+}
+
+func (c *ActiveController) WatchConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsDiff)) error {
+	return c.streamClient().WatchConnections(ctx, interval, callback)
+}
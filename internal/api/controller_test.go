@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/api/apitest"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+func TestActiveControllerSwitch(t *testing.T) {
+	first := apitest.NewServer()
+	defer first.Close()
+	second := apitest.NewServer()
+	defer second.Close()
+	second.Update(func(state *apitest.State) {
+		state.Version.Version = "v1.19.1"
+	})
+	second.SetSecret("s3cret")
+
+	controller := NewActiveController(config.APIConfig{BaseURL: first.URL})
+	defer controller.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	traffic := make(chan *models.Traffic, 1)
+	go Supervise(ctx, "traffic", func(ctx context.Context, alive func()) error {
+		return controller.StreamTraffic(ctx, func(m *models.Traffic) {
+			alive()
+			traffic <- m
+		})
+	}, nil)
+	if !first.WaitSubscribers(apitest.StreamTraffic, 1, 5*time.Second) {
+		t.Fatal("stream never reached the first controller")
+	}
+
+	controller.Switch(config.APIConfig{BaseURL: second.URL, Secret: "s3cret"})
+
+	version, err := controller.GetVersion(ctx)
+	if err != nil || version.Version != "v1.19.1" {
+		t.Errorf("GetVersion after switching = %+v, %v", version, err)
+	}
+
+	// The supervised stream follows the switch
+	if !second.WaitSubscribers(apitest.StreamTraffic, 1, 5*time.Second) {
+		t.Fatal("stream never reached the second controller")
+	}
+	second.PublishTraffic(10, 20)
+	if got := receive(t, traffic); got.Up != 10 || got.Down != 20 {
+		t.Errorf("traffic = %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"mihomoTui/internal/models"
+)
+
+// ErrorKind classifies why a controller request failed
+type ErrorKind int
+
+const (
+	KindUnknown      ErrorKind = iota
+	KindUnauthorized           // Missing or wrong secret
+	KindNotFound               // Unknown proxy, group, provider or endpoint
+	KindTimeout                // The core did not answer in time
+	KindUnreachable            // No connection to the controller
+	KindBadRequest             // The core rejected the request
+	KindServer                 // The core failed while handling the request
+	KindTLS                    // TLS handshake failed or TLS settings are invalid
+	KindSSH                    // The SSH tunnel to the controller host failed
+)
+
+// String returns the name of the error kind
+func (k ErrorKind) String() string {
+	switch k {
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindNotFound:
+		return "not found"
+	case KindTimeout:
+		return "timeout"
+	case KindUnreachable:
+		return "unreachable"
+	case KindBadRequest:
+		return "bad request"
+	case KindServer:
+		return "server error"
+	case KindTLS:
+		return "tls"
+	case KindSSH:
+		return "ssh"
+	default:
+		return "unknown"
+	}
+}
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 4 << 10
+
+// APIError describes a failed request to the controller
+type APIError struct {
+	Kind       ErrorKind
+	StatusCode int // Zero when no response was received
+	Method     string
+	Endpoint   string
+	Message    string // Message decoded from the core's response body
+	Err        error  // Underlying transport error, if any
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %s", e.Method, e.Endpoint, e.Kind)
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, " (status %d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	} else if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying transport error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsKind reports whether err is an APIError of the given kind
+func IsKind(err error, kind ErrorKind) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Kind == kind
+}
+
+// newStatusError builds an APIError from an unexpected response, keeping the
+// message the core put in the body. The caller still closes the body.
+func newStatusError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		Kind:       statusKind(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Endpoint = resp.Request.URL.Path
+	}
+
+	if resp.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		apiErr.Message = decodeErrorMessage(body)
+	}
+
+	return apiErr
+}
+
+// newTransportError wraps an error raised while sending a request
+func newTransportError(method, endpoint string, err error) *APIError {
+	kind := KindUnreachable
+	var (
+		netErr net.Error
+		sshErr *SSHError
+	)
+	switch {
+	case errors.As(err, &sshErr):
+		kind = KindSSH
+	case isTLSError(err):
+		kind = KindTLS
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		kind = KindTimeout
+	}
+
+	return &APIError{
+		Kind:     kind,
+		Method:   method,
+		Endpoint: endpoint,
+		Err:      err,
+	}
+}
+
+// statusKind maps an HTTP status code to an error kind
+func statusKind(status int) ErrorKind {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return KindUnauthorized
+	case status == http.StatusNotFound:
+		return KindNotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return KindTimeout
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable:
+		return KindUnreachable
+	case status >= 400 && status < 500:
+		return KindBadRequest
+	case status >= 500:
+		return KindServer
+	default:
+		return KindUnknown
+	}
+}
+
+// decodeErrorMessage extracts {"message"} or {"error"} from an error body,
+// falling back to the raw text for cores that answer in plain text
+func decodeErrorMessage(body []byte) string {
+	var apiResp models.APIResponse
+	if err := json.Unmarshal(body, &apiResp); err == nil {
+		if apiResp.Message != "" {
+			return apiResp.Message
+		}
+		return apiResp.Error
+	}
+	return strings.TrimSpace(string(body))
+}
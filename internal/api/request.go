@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// requestPath builds a controller endpoint from path segments and query
+// parameters. Every segment is escaped on its own, so proxy, group and
+// provider names may contain '/', '#', '%', spaces or emoji.
+type requestPath struct {
+	segments []string
+	query    url.Values
+}
+
+// newPath starts an endpoint with the given segments, e.g.
+// newPath("proxies", name, "delay") for /proxies/{name}/delay
+func newPath(segments ...string) *requestPath {
+	return &requestPath{segments: segments}
+}
+
+// Param adds a query parameter, skipping empty values
+func (p *requestPath) Param(key, value string) *requestPath {
+	if value == "" {
+		return p
+	}
+	if p.query == nil {
+		p.query = url.Values{}
+	}
+	p.query.Set(key, value)
+	return p
+}
+
+// IntParam adds an integer query parameter
+func (p *requestPath) IntParam(key string, value int) *requestPath {
+	return p.Param(key, strconv.Itoa(value))
+}
+
+// String returns the escaped endpoint, ready to append to the base URL
+func (p *requestPath) String() string {
+	var b strings.Builder
+	for _, segment := range p.segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(segment))
+	}
+	if b.Len() == 0 {
+		b.WriteByte('/')
+	}
+	if len(p.query) > 0 {
+		b.WriteByte('?')
+		b.WriteString(p.query.Encode())
+	}
+	return b.String()
+}
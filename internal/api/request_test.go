@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// nodeNames is a corpus of node names seen in real subscriptions
+var nodeNames = []string{
+	"Hong Kong 01",
+	"🇭🇰 香港 IPLC 01",
+	"🇯🇵 日本 | 东京 x1.5",
+	"US/LA-02",
+	"SG #3 [Premium]",
+	"100% uptime",
+	"a%2Fb",
+	"rate=2&tag=game",
+	"what?now",
+	"semi;colon,comma",
+	"plus+sign",
+	"tab\tinside",
+	"trailing space ",
+	"..",
+	"♻️ 自动选择",
+	"🎯 全球直连",
+}
+
+// lastSegment returns the unescaped final path segment of an escaped path
+func lastSegment(t *testing.T, escapedPath string) string {
+	t.Helper()
+	raw := escapedPath[strings.LastIndex(escapedPath, "/")+1:]
+	segment, err := url.PathUnescape(raw)
+	if err != nil {
+		t.Fatalf("unescape %q: %v", raw, err)
+	}
+	return segment
+}
+
+func TestRequestPathEscapesSegments(t *testing.T) {
+	for _, name := range nodeNames {
+		endpoint := newPath("proxies", name).String()
+
+		u, err := url.Parse("http://127.0.0.1:9090" + endpoint)
+		if err != nil {
+			t.Fatalf("%q: parse %q: %v", name, endpoint, err)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			t.Errorf("%q: leaked into query %q or fragment %q", name, u.RawQuery, u.Fragment)
+		}
+
+		segments := strings.Split(u.EscapedPath(), "/")
+		if len(segments) != 3 {
+			t.Errorf("%q: got %d segments in %q, want 3", name, len(segments), u.EscapedPath())
+			continue
+		}
+		if got := lastSegment(t, u.EscapedPath()); got != name {
+			t.Errorf("round trip: got %q, want %q", got, name)
+		}
+	}
+}
+
+func TestRequestPathQuery(t *testing.T) {
+	testURL := "http://example.com/generate_204?a=1&b=2#frag"
+	endpoint := newPath("proxies", "US/LA #2", "delay").
+		Param("url", testURL).
+		IntParam("timeout", 5000).
+		Param("empty", "").
+		String()
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatalf("parse %q: %v", endpoint, err)
+	}
+
+	query := u.Query()
+	if got := query.Get("url"); got != testURL {
+		t.Errorf("url param: got %q, want %q", got, testURL)
+	}
+	if got := query.Get("timeout"); got != "5000" {
+		t.Errorf("timeout param: got %q, want 5000", got)
+	}
+	if query.Has("empty") {
+		t.Errorf("empty param should be omitted, got %q", u.RawQuery)
+	}
+	if got, want := u.Path, "/proxies/US/LA #2/delay"; got != want {
+		t.Errorf("path: got %q, want %q", got, want)
+	}
+}
+
+func TestRequestPathRoot(t *testing.T) {
+	if got := newPath().String(); got != "/" {
+		t.Errorf("got %q, want /", got)
+	}
+}
+
+// TestClientSendsEscapedNames checks what a controller actually receives
+func TestClientSendsEscapedNames(t *testing.T) {
+	var gotPath, gotURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotURL = r.URL.Query().Get("url")
+		switch {
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(gotPath, "/delay"):
+			json.NewEncoder(w).Encode(map[string]int{"delay": 42})
+		default:
+			json.NewEncoder(w).Encode(map[string]string{"name": lastSegment(t, gotPath)})
+		}
+	}))
+	defer server.Close()
+
+	client := &HttpClient{baseURL: server.URL, httpClient: server.Client()}
+	ctx := context.Background()
+	testURL := "https://www.gstatic.com/generate_204?x=1&y=2"
+
+	for _, name := range nodeNames {
+		proxy, err := client.GetProxy(ctx, name)
+		if err != nil {
+			t.Fatalf("GetProxy(%q): %v", name, err)
+		}
+		if proxy.Name != name {
+			t.Errorf("GetProxy: server saw %q, want %q", proxy.Name, name)
+		}
+
+		if err := client.SelectProxy(ctx, name, name); err != nil {
+			t.Fatalf("SelectProxy(%q): %v", name, err)
+		}
+		if got := lastSegment(t, gotPath); got != name {
+			t.Errorf("SelectProxy: server saw %q, want %q", got, name)
+		}
+
+		delay, err := client.TestProxyDelay(ctx, name, testURL, 5000)
+		if err != nil {
+			t.Fatalf("TestProxyDelay(%q): %v", name, err)
+		}
+		if delay != 42 {
+			t.Errorf("TestProxyDelay(%q): got delay %d", name, delay)
+		}
+		if gotURL != testURL {
+			t.Errorf("TestProxyDelay: server saw url %q, want %q", gotURL, testURL)
+		}
+		segments := strings.Split(gotPath, "/")
+		if got, _ := url.PathUnescape(segments[2]); len(segments) != 4 || got != name {
+			t.Errorf("TestProxyDelay: server saw path %q for %q", gotPath, name)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mihomoTui/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// sshHandshakeTimeout bounds dialling and authenticating to the SSH server
+	sshHandshakeTimeout = 15 * time.Second
+
+	// sshKeepAliveInterval is how often an idle connection is checked, so a
+	// silently dropped link is noticed before the next request hangs
+	sshKeepAliveInterval = 30 * time.Second
+)
+
+// SSHFailure classifies why the SSH tunnel could not be opened
+type SSHFailure int
+
+const (
+	SSHConnectFailed   SSHFailure = iota // SSH server unreachable or handshake failed
+	SSHConfigInvalid                     // Key or known_hosts file could not be loaded
+	SSHHostUnknown                       // Server key is not in known_hosts
+	SSHHostKeyMismatch                   // Server key differs from known_hosts
+	SSHAuthFailed                        // Server rejected the user or key
+)
+
+// SSHError reports a failure to open the SSH connection itself, as opposed
+// to the controller refusing a forwarded connection
+type SSHError struct {
+	Failure SSHFailure
+	Host    string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *SSHError) Error() string {
+	return fmt.Sprintf("ssh %s: %v", e.Host, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *SSHError) Unwrap() error {
+	return e.Err
+}
+
+// permanent reports whether retrying can't help until the settings change
+func (e *SSHError) permanent() bool {
+	return e.Failure != SSHConnectFailed
+}
+
+// sshTunnel opens controller connections through a shared SSH connection,
+// redialling the server whenever that connection has died
+type sshTunnel struct {
+	cfg  config.SSHConfig
+	host string // host:port of the SSH server
+
+	mutex  sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// newSSHTunnel creates a tunnel for cfg, or returns nil when SSH is not
+// configured. Nothing is dialled until the first connection is needed.
+func newSSHTunnel(cfg config.SSHConfig) *sshTunnel {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	host := cfg.Host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+
+	return &sshTunnel{cfg: cfg, host: host}
+}
+
+// DialContext opens a connection to addr as seen from the SSH server.
+// network is "tcp" for controller ports or "unix" for socket paths.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh forward to %s: %w", addr, err)
+	}
+
+	return conn, nil
+}
+
+// Close shuts the SSH connection down for good
+func (t *sshTunnel) Close() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.closed = true
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+// connect returns the live SSH connection, dialling a new one if needed
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil, &SSHError{Failure: SSHConnectFailed, Host: t.host, Err: errors.New("tunnel closed")}
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	client, err := t.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.client = client
+
+	// Forget the connection once it dies so the next dial reconnects
+	done := make(chan struct{})
+	go func() {
+		err := client.Wait()
+		close(done)
+		slog.Info("SSH connection closed", "host", t.host, "err", err)
+		t.drop(client)
+	}()
+	go t.keepAlive(client, done)
+
+	slog.Info("SSH connection established", "host", t.host)
+	return client, nil
+}
+
+// drop forgets client if it is still the current connection
+func (t *sshTunnel) drop(client *ssh.Client) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.client == client {
+		t.client = nil
+		client.Close()
+	}
+}
+
+// keepAlive closes client when the server stops answering, which ends
+// client.Wait and so drops the connection
+func (t *sshTunnel) keepAlive(client *ssh.Client, done <-chan struct{}) {
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		replied := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			replied <- err
+		}()
+
+		select {
+		case <-done:
+			return
+		case err := <-replied:
+			if err == nil {
+				continue
+			}
+			slog.Warn("SSH keepalive failed", "host", t.host, "err", err)
+		case <-time.After(sshHandshakeTimeout):
+			slog.Warn("SSH keepalive timed out", "host", t.host)
+		}
+		client.Close()
+		return
+	}
+}
+
+// dial connects and authenticates to the SSH server
+func (t *sshTunnel) dial(ctx context.Context) (*ssh.Client, error) {
+	clientConfig, release, err := t.clientConfig()
+	if err != nil {
+		return nil, &SSHError{Failure: SSHConfigInvalid, Host: t.host, Err: err}
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, sshHandshakeTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", t.host)
+	if err != nil {
+		return nil, &SSHError{Failure: SSHConnectFailed, Host: t.host, Err: err}
+	}
+
+	// The handshake has no context of its own
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, t.host, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, &SSHError{Failure: classifySSHError(err), Host: t.host, Err: err}
+	}
+	conn.SetDeadline(time.Time{})
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// clientConfig loads the credentials and known hosts for the tunnel.
+// release must be called once the handshake is over.
+func (t *sshTunnel) clientConfig() (clientConfig *ssh.ClientConfig, release func(), err error) {
+	userName := t.cfg.User
+	if userName == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, nil, fmt.Errorf("no SSH user configured: %w", err)
+		}
+		userName = current.Username
+	}
+
+	knownHostsFile := t.cfg.KnownHostsFile
+	if knownHostsFile == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to locate known_hosts: %w", err)
+		}
+		knownHostsFile = filepath.Join(homeDir, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(expandHome(knownHostsFile))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	auth, release, err := t.authMethod()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            userName,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshHandshakeTimeout,
+	}, release, nil
+}
+
+// authMethod uses the configured key file, or the running ssh-agent.
+// The agent connection is opened per handshake, so a restarted agent is
+// picked up, and is closed by release.
+func (t *sshTunnel) authMethod() (auth ssh.AuthMethod, release func(), err error) {
+	if t.cfg.KeyFile != "" {
+		pem, err := os.ReadFile(expandHome(t.cfg.KeyFile))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			var passphraseErr *ssh.PassphraseMissingError
+			if errors.As(err, &passphraseErr) {
+				return nil, nil, errors.New("SSH key is encrypted; load it into ssh-agent and leave the key file empty")
+			}
+			return nil, nil, fmt.Errorf("failed to parse SSH key: %w", err)
+		}
+		return ssh.PublicKeys(signer), func() {}, nil
+	}
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil, errors.New("no SSH key file configured and ssh-agent is not running")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach ssh-agent: %w", err)
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), func() { conn.Close() }, nil
+}
+
+// classifySSHError tells host key problems and rejected credentials apart
+// from plain connection failures
+func classifySSHError(err error) SSHFailure {
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) {
+		if len(keyErr.Want) == 0 {
+			return SSHHostUnknown
+		}
+		return SSHHostKeyMismatch
+	}
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return SSHAuthFailed
+	}
+	return SSHConnectFailed
+}
+
+// expandHome resolves a leading ~/ in a path
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshServer is an in-process SSH server that only forwards TCP connections
+type sshServer struct {
+	listener  net.Listener
+	hostKey   ssh.Signer
+	forwarded atomic.Int32
+
+	mutex sync.Mutex
+	conns []net.Conn
+}
+
+// newSSHServer starts a server that accepts clients holding authorized
+func newSSHServer(t *testing.T, authorized ssh.PublicKey) *sshServer {
+	t.Helper()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &sshServer{listener: listener, hostKey: hostKey}
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	go s.serve(serverConfig)
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *sshServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *sshServer) serve(serverConfig *ssh.ServerConfig) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+
+		go func() {
+			_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			for newChannel := range chans {
+				go s.forward(newChannel)
+			}
+		}()
+	}
+}
+
+// forward serves a direct-tcpip channel by dialling the requested address
+func (s *sshServer) forward(newChannel ssh.NewChannel) {
+	if newChannel.ChannelType() != "direct-tcpip" {
+		newChannel.Reject(ssh.UnknownChannelType, "only direct-tcpip is supported")
+		return
+	}
+
+	var payload struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	s.forwarded.Add(1)
+
+	go func() {
+		io.Copy(channel, target)
+		channel.CloseWrite()
+	}()
+	io.Copy(target, channel)
+	target.Close()
+	channel.Close()
+}
+
+// dropConnections closes every client connection, as a restarted sshd would
+func (s *sshServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *sshServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+}
+
+// sshFixture holds the files a client needs to reach an sshServer
+type sshFixture struct {
+	server     *sshServer
+	keyFile    string
+	clientKey  ssh.PublicKey
+	knownHosts string
+}
+
+func newSSHFixture(t *testing.T) *sshFixture {
+	t.Helper()
+	dir := t.TempDir()
+
+	clientPub, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	clientKey, err := ssh.NewPublicKey(clientPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newSSHServer(t, clientKey)
+	f := &sshFixture{
+		server:     server,
+		keyFile:    keyFile,
+		clientKey:  clientKey,
+		knownHosts: filepath.Join(dir, "known_hosts"),
+	}
+	f.trust(t, server.hostKey.PublicKey())
+	return f
+}
+
+// trust writes known_hosts so the server's address maps to key
+func (f *sshFixture) trust(t *testing.T, key ssh.PublicKey) {
+	t.Helper()
+	line := knownhosts.Line([]string{knownhosts.Normalize(f.server.addr())}, key)
+	if err := os.WriteFile(f.knownHosts, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *sshFixture) config(controller string) config.APIConfig {
+	return config.APIConfig{
+		BaseURL: controller,
+		SSH: config.SSHConfig{
+			Host:           f.server.addr(),
+			User:           "tester",
+			KeyFile:        f.keyFile,
+			KnownHostsFile: f.knownHosts,
+		},
+	}
+}
+
+func newVersionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"version": "v1.19.0", "meta": true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSSHTunnelForwardsRequests(t *testing.T) {
+	fixture := newSSHFixture(t)
+	controller := newVersionServer(t)
+
+	client := NewHttpClient(fixture.config(controller.URL), 5*time.Second)
+	defer client.Close()
+
+	version, err := client.GetVersion(context.Background())
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if version.Version != "v1.19.0" {
+		t.Errorf("version = %q, want v1.19.0", version.Version)
+	}
+	if fixture.server.forwarded.Load() == 0 {
+		t.Error("request did not go through the SSH server")
+	}
+}
+
+func TestSSHTunnelFailures(t *testing.T) {
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ssh.NewPublicKey(otherPub)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, f *sshFixture, cfg *config.APIConfig)
+		want  SSHFailure
+	}{
+		{
+			name: "unknown host",
+			setup: func(t *testing.T, f *sshFixture, _ *config.APIConfig) {
+				os.WriteFile(f.knownHosts, nil, 0o600)
+			},
+			want: SSHHostUnknown,
+		},
+		{
+			name: "host key mismatch",
+			setup: func(t *testing.T, f *sshFixture, _ *config.APIConfig) {
+				f.trust(t, otherKey)
+			},
+			want: SSHHostKeyMismatch,
+		},
+		{
+			name: "rejected key",
+			setup: func(t *testing.T, f *sshFixture, cfg *config.APIConfig) {
+				_, priv, _ := ed25519.GenerateKey(rand.Reader)
+				block, _ := ssh.MarshalPrivateKey(priv, "")
+				keyFile := filepath.Join(t.TempDir(), "other")
+				os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600)
+				cfg.SSH.KeyFile = keyFile
+			},
+			want: SSHAuthFailed,
+		},
+		{
+			name: "missing key file",
+			setup: func(t *testing.T, f *sshFixture, cfg *config.APIConfig) {
+				cfg.SSH.KeyFile = filepath.Join(t.TempDir(), "missing")
+			},
+			want: SSHConfigInvalid,
+		},
+	}
+
+	controller := newVersionServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := newSSHFixture(t)
+			cfg := fixture.config(controller.URL)
+			tt.setup(t, fixture, &cfg)
+
+			client := NewHttpClient(cfg, 5*time.Second)
+			defer client.Close()
+
+			_, err := client.GetVersion(context.Background())
+			if !IsKind(err, KindSSH) {
+				t.Fatalf("GetVersion error = %v, want kind ssh", err)
+			}
+			var sshErr *SSHError
+			if !errors.As(err, &sshErr) || sshErr.Failure != tt.want {
+				t.Errorf("failure = %v, want %v", sshErr.Failure, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSHTunnelReconnects(t *testing.T) {
+	fixture := newSSHFixture(t)
+	controller := newVersionServer(t)
+
+	client := NewHttpClient(fixture.config(controller.URL), 5*time.Second)
+	defer client.Close()
+
+	ctx := context.Background()
+	if _, err := client.GetVersion(ctx); err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+
+	fixture.server.dropConnections()
+
+	// Wait for the tunnel to notice the dead connection
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.tunnel.mutex.Lock()
+		dropped := client.tunnel.client == nil
+		client.tunnel.mutex.Unlock()
+		if dropped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tunnel kept the closed SSH connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.GetVersion(ctx); err != nil {
+		t.Fatalf("GetVersion after reconnect: %v", err)
+	}
+	if got := fixture.server.forwarded.Load(); got < 2 {
+		t.Errorf("forwarded %d connections, want a new one after reconnecting", got)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ConnState describes the link between the TUI and the controller
+type ConnState int
+
+const (
+	StateConnecting   ConnState = iota // No stream has reported yet
+	StateConnected                     // At least one stream is receiving data
+	StateReconnecting                  // Streams dropped and are retrying
+	StateDown                          // Retries keep failing
+)
+
+// String returns the name of the state
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateDown:
+		return "down"
+	default:
+		return "connecting"
+	}
+}
+
+// downAfter is the number of consecutive failed attempts before the
+// controller is reported down rather than reconnecting
+const downAfter = 3
+
+// ConnStatus is a snapshot of the connection state
+type ConnStatus struct {
+	State   ConnState
+	LastErr error // Most recent failure, kept after reconnecting
+	Since   time.Time
+}
+
+// connMonitor publishes the connection state fed by stream supervisors
+type connMonitor struct {
+	mutex       sync.Mutex
+	status      ConnStatus
+	subscribers map[int]func(ConnStatus)
+	nextID      int
+
+	// Closed and replaced whenever the controller address or secret changes
+	changed chan struct{}
+}
+
+var monitor = &connMonitor{
+	status:      ConnStatus{State: StateConnecting, Since: time.Now()},
+	subscribers: make(map[int]func(ConnStatus)),
+	changed:     make(chan struct{}),
+}
+
+// CurrentStatus returns the current connection state
+func CurrentStatus() ConnStatus {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	return monitor.status
+}
+
+// SubscribeStatus calls fn with the current state and on every change until
+// the returned function is called. fn runs on the reporting goroutine and
+// must not block on the UI thread.
+func SubscribeStatus(fn func(ConnStatus)) (unsubscribe func()) {
+	monitor.mutex.Lock()
+	id := monitor.nextID
+	monitor.nextID++
+	monitor.subscribers[id] = fn
+	status := monitor.status
+	monitor.mutex.Unlock()
+
+	fn(status)
+
+	return func() {
+		monitor.mutex.Lock()
+		delete(monitor.subscribers, id)
+		monitor.mutex.Unlock()
+	}
+}
+
+// configChanged returns a channel closed on the next controller change
+func configChanged() <-chan struct{} {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	return monitor.changed
+}
+
+// reportConnected records that a stream is receiving data
+func reportConnected() {
+	monitor.set(StateConnected, nil)
+}
+
+// reportFailure records a failed stream attempt. Errors that only concern a
+// single endpoint, like a missing feature, don't say anything about the link.
+func reportFailure(err error, attempt int) {
+	if IsKind(err, KindNotFound) || IsKind(err, KindBadRequest) {
+		return
+	}
+
+	state := StateReconnecting
+	// Retrying won't fix a wrong secret, a rejected certificate or SSH
+	// credentials
+	var sshErr *SSHError
+	if attempt >= downAfter || IsKind(err, KindUnauthorized) || IsKind(err, KindTLS) ||
+		(errors.As(err, &sshErr) && sshErr.permanent()) {
+		state = StateDown
+	}
+	monitor.set(state, err)
+}
+
+// notifyConfigChanged wakes every supervisor so it reconnects immediately
+func notifyConfigChanged() {
+	monitor.mutex.Lock()
+	close(monitor.changed)
+	monitor.changed = make(chan struct{})
+	monitor.mutex.Unlock()
+
+	monitor.set(StateConnecting, nil)
+}
+
+// set updates the state and notifies subscribers when it changed
+func (m *connMonitor) set(state ConnState, err error) {
+	m.mutex.Lock()
+	if m.status.State == state && err == nil {
+		m.mutex.Unlock()
+		return
+	}
+
+	if state != m.status.State {
+		m.status.Since = time.Now()
+	}
+	m.status.State = state
+	if err != nil {
+		m.status.LastErr = err
+	} else if state == StateConnecting {
+		m.status.LastErr = nil
+	}
+
+	status := m.status
+	subscribers := make([]func(ConnStatus), 0, len(m.subscribers))
+	for _, fn := range m.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	m.mutex.Unlock()
+
+	for _, fn := range subscribers {
+		fn(status)
+	}
+}
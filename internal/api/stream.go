@@ -4,60 +4,78 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"mihomoTui/internal/models"
 	"net/http"
 	"strings"
+	"time"
 )
 
-// GetMemoryUsage retrieves the current memory usage
+// maxStreamLineSize bounds a single chunked message (connection snapshots can be large)
+const maxStreamLineSize = 4 * 1024 * 1024
+
+// streamTransport delivers raw JSON messages from endpoint to onMessage until
+// the stream ends or ctx is cancelled
+type streamTransport func(ctx context.Context, endpoint string, onMessage func([]byte)) error
+
+// StreamMemoryUsage streams the core's memory usage
 func (c *HttpClient) StreamMemoryUsage(ctx context.Context, callback func(*models.MemoryUsage)) error {
-	resp, err := c.makeRequest("GET", "/memory", nil)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return c.stream(ctx, "/memory", jsonHandler(callback), c.streamChunked)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
+// StreamLogs streams real-time logs
+func (c *HttpClient) StreamLogs(ctx context.Context, callback func(*models.Log)) error {
+	return c.stream(ctx, "/logs", jsonHandler(callback), c.streamChunked)
+}
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
+// StreamTraffic streams real-time traffic data
+func (c *HttpClient) StreamTraffic(ctx context.Context, callback func(*models.Traffic)) error {
+	return c.stream(ctx, "/traffic", jsonHandler(callback), c.streamChunked)
+}
 
-			var memUsage models.MemoryUsage
-			if err := json.Unmarshal([]byte(line), &memUsage); err != nil {
-				continue // Skip invalid JSON
-			}
+// StreamConnections streams connection snapshots every interval.
+// The chunked fallback polls, since /connections over plain HTTP only
+// returns a single snapshot.
+func (c *HttpClient) StreamConnections(ctx context.Context, interval time.Duration, callback func(*models.ConnectionsSnapshot)) error {
+	endpoint := newPath("connections").IntParam("interval", int(interval.Milliseconds())).String()
+	return c.stream(ctx, endpoint, jsonHandler(callback), c.pollTransport(interval))
+}
 
-			callback(&memUsage)
+// stream negotiates WebSocket for endpoint and falls back to the given
+// transport when the controller (or a proxy in front of it) refuses the
+// upgrade. The refusal is remembered per endpoint, so one endpoint behind a
+// proxy that drops upgrades doesn't move every stream to plain HTTP.
+func (c *HttpClient) stream(ctx context.Context, endpoint string, onMessage func([]byte), fallback streamTransport) error {
+	path, _, _ := strings.Cut(endpoint, "?")
+	if _, unsupported := c.wsUnsupported.Load(path); !unsupported {
+		err := c.streamWebSocket(ctx, endpoint, onMessage)
+		if !errors.Is(err, errWebSocketUnsupported) {
+			return err
 		}
+		// Remember the result so later streams skip the failed handshake
+		c.wsUnsupported.Store(path, struct{}{})
+		slog.Info("WebSocket unavailable, falling back to HTTP", "endpoint", path, "err", err)
 	}
 
-	return scanner.Err()
+	return fallback(ctx, endpoint, onMessage)
 }
 
-// StreamLogs streams real-time logs via chunked transfer
-func (c *HttpClient) StreamLogs(ctx context.Context, callback func(*models.Log)) error {
-	resp, err := c.makeRequest("GET", "/logs", nil)
+// streamChunked reads newline-delimited JSON from a chunked HTTP response
+func (c *HttpClient) streamChunked(ctx context.Context, endpoint string, onMessage func([]byte)) error {
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+		return newStatusError(resp)
 	}
 
 	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
@@ -68,49 +86,67 @@ func (c *HttpClient) StreamLogs(ctx context.Context, callback func(*models.Log))
 				continue
 			}
 
-			var log models.Log
-			if err := json.Unmarshal([]byte(line), &log); err != nil {
-				continue // Skip invalid JSON
-			}
-
-			callback(&log)
+			onMessage([]byte(line))
 		}
 	}
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	return scanner.Err()
 }
 
-// StreamTraffic streams real-time traffic data via chunked transfer
-func (c *HttpClient) StreamTraffic(ctx context.Context, callback func(*models.Traffic)) error {
-	resp, err := c.makeRequest("GET", "/traffic", nil)
+// pollTransport returns a transport that fetches endpoint every interval,
+// for endpoints that only stream over WebSocket
+func (c *HttpClient) pollTransport(interval time.Duration) streamTransport {
+	return func(ctx context.Context, endpoint string, onMessage func([]byte)) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			data, err := c.fetchRaw(ctx, endpoint)
+			if err != nil {
+				return err
+			}
+			onMessage(data)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// fetchRaw performs a GET request and returns the raw response body
+func (c *HttpClient) fetchRaw(ctx context.Context, endpoint string) ([]byte, error) {
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
-			var traffic models.Traffic
-			if err := json.Unmarshal([]byte(line), &traffic); err != nil {
-				continue // Skip invalid JSON
-			}
+	return raw, nil
+}
 
-			callback(&traffic)
+// jsonHandler decodes each message into T before handing it to callback
+func jsonHandler[T any](callback func(*T)) func([]byte) {
+	return func(data []byte) {
+		var value T
+		if err := json.Unmarshal(data, &value); err != nil {
+			return // Skip invalid JSON
 		}
-	}
 
-	return scanner.Err()
+		callback(&value)
+	}
 }
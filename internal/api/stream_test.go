@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+func TestWebSocketFallbackPerEndpoint(t *testing.T) {
+	// A core lacking /memory that only streams /traffic over WebSocket, so
+	// a fallback to chunked HTTP would fail
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/memory", http.NotFound)
+	mux.HandleFunc("/traffic", func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) {
+			http.Error(w, "websocket only", http.StatusInternalServerError)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(models.Traffic{Up: 1, Down: 2})
+		conn.ReadMessage() // Hold the stream open until the client leaves
+	})
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			http.Error(w, "upgrade refused", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"type":"info","payload":"chunked"}` + "\n"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewHttpClient(config.APIConfig{BaseURL: server.URL}, 0)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A missing endpoint is a regular error, not a reason to drop WebSocket
+	for range 2 {
+		err := client.StreamMemoryUsage(ctx, func(*models.MemoryUsage) {})
+		if !IsKind(err, KindNotFound) {
+			t.Fatalf("StreamMemoryUsage = %v, want not found", err)
+		}
+	}
+
+	// A refused upgrade only moves that endpoint to chunked HTTP
+	logs := make(chan *models.Log, 1)
+	if err := client.StreamLogs(ctx, func(m *models.Log) { logs <- m }); err != nil {
+		t.Fatalf("StreamLogs: %v", err)
+	}
+	if got := receive(t, logs); got.Payload != "chunked" {
+		t.Errorf("log = %+v", got)
+	}
+
+	traffic := make(chan *models.Traffic, 1)
+	streamCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- client.StreamTraffic(streamCtx, func(m *models.Traffic) { traffic <- m }) }()
+	if got := receive(t, traffic); got.Up != 1 || got.Down != 2 {
+		t.Errorf("traffic = %+v", got)
+	}
+	stop()
+	receive(t, done)
+}
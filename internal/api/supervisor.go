@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// errStreamClosed reports a stream the controller ended without an error
+var errStreamClosed = errors.New("stream closed by controller")
+
+// Backoff configures the delay between reconnect attempts
+type Backoff struct {
+	Initial time.Duration // Delay before the first retry
+	Max     time.Duration // Upper bound for any delay
+	Factor  float64       // Growth per failed attempt
+	Jitter  float64       // Fraction of the delay randomised, 0 to 1
+}
+
+// DefaultBackoff is used by Supervise
+var DefaultBackoff = Backoff{
+	Initial: 500 * time.Millisecond,
+	Max:     30 * time.Second,
+	Factor:  2,
+	Jitter:  0.2,
+}
+
+// Delay returns the wait before the given attempt, counting from 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial)
+	for i := 1; i < attempt && delay < float64(b.Max); i++ {
+		delay *= b.Factor
+	}
+	delay = min(delay, float64(b.Max))
+
+	if b.Jitter > 0 {
+		// Spread retries so every stream doesn't hit the core at once
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(min(delay, float64(b.Max)))
+}
+
+// ConnectFunc opens a stream and blocks until it ends. It calls alive once
+// data arrives so the supervisor knows the attempt succeeded.
+type ConnectFunc func(ctx context.Context, alive func()) error
+
+// Supervise keeps a stream open until ctx is cancelled. Failed attempts are
+// retried with DefaultBackoff, and a controller change restarts the stream
+// at once. onError, if set, is called after every failed attempt.
+func Supervise(ctx context.Context, name string, connect ConnectFunc, onError func(error)) {
+	supervise(ctx, name, connect, onError, true)
+}
+
+// SuperviseUntracked is Supervise for a controller other than the active
+// one: its failures don't affect the connection state, and a change of the
+// active controller doesn't restart it.
+func SuperviseUntracked(ctx context.Context, name string, connect ConnectFunc, onError func(error)) {
+	supervise(ctx, name, connect, onError, false)
+}
+
+// supervise runs the reconnect loop, publishing the connection state and
+// following controller changes when tracked is set
+func supervise(ctx context.Context, name string, connect ConnectFunc, onError func(error), tracked bool) {
+	attempt := 0
+	for {
+		// A nil channel never fires, so untracked streams ignore changes
+		var changed <-chan struct{}
+		if tracked {
+			changed = configChanged()
+		}
+		attemptCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-changed:
+				cancel()
+			case <-attemptCtx.Done():
+			}
+		}()
+
+		var healthy atomic.Bool
+		started := time.Now()
+		err := connect(attemptCtx, func() {
+			if healthy.CompareAndSwap(false, true) && tracked {
+				reportConnected()
+			}
+		})
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if isClosed(changed) {
+			attempt = 0
+			continue
+		}
+
+		// Quiet streams like logs may never call alive; one that stayed
+		// open for a while was still a working connection
+		if healthy.Load() || time.Since(started) > DefaultBackoff.Max {
+			attempt = 0
+		}
+		attempt++
+		if err == nil {
+			err = errStreamClosed
+		}
+
+		if tracked {
+			reportFailure(err, attempt)
+		}
+		if onError != nil {
+			onError(err)
+		}
+
+		delay := DefaultBackoff.Delay(attempt)
+		slog.Warn("Stream failed", "stream", name, "attempt", attempt, "retry_in", delay.Round(time.Millisecond), "err", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-changed:
+			timer.Stop()
+			attempt = 0
+		case <-timer.C:
+		}
+	}
+}
+
+// isClosed reports whether ch has been closed
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
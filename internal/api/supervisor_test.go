@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Factor: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second}, // Clamped to Max
+		{100, time.Second},
+	}
+	for _, test := range tests {
+		if got := backoff.Delay(test.attempt); got != test.want {
+			t.Errorf("Delay(%d) = %v, want %v", test.attempt, got, test.want)
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Factor: 2, Jitter: 0.2}
+	exact := backoff
+	exact.Jitter = 0
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		base := exact.Delay(attempt)
+		low := time.Duration(float64(base) * 0.8)
+		high := min(time.Duration(float64(base)*1.2), backoff.Max)
+
+		for range 200 {
+			if got := backoff.Delay(attempt); got < low || got > high {
+				t.Fatalf("Delay(%d) = %v, want within [%v, %v]", attempt, got, low, high)
+			}
+		}
+	}
+}
+
+// fastBackoff makes Supervise retry at once for the rest of the test
+func fastBackoff(t *testing.T) {
+	saved := DefaultBackoff
+	DefaultBackoff = Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Factor: 2}
+	t.Cleanup(func() { DefaultBackoff = saved })
+}
+
+// recordStates collects the connection states published from now on
+func recordStates(t *testing.T) func() []ConnState {
+	var (
+		mutex  sync.Mutex
+		states []ConnState
+	)
+	notifyConfigChanged() // Start from a clean connecting state
+	unsubscribe := SubscribeStatus(func(status ConnStatus) {
+		mutex.Lock()
+		states = append(states, status.State)
+		mutex.Unlock()
+	})
+	t.Cleanup(unsubscribe)
+
+	return func() []ConnState {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return slices.Clone(states)
+	}
+}
+
+func TestSupervise(t *testing.T) {
+	fastBackoff(t)
+	states := recordStates(t)
+
+	refused := &APIError{Kind: KindUnreachable, Method: "GET", Endpoint: "/traffic", Err: errors.New("connection refused")}
+	waiting := make(chan struct{})
+	attempts := 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var failures []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Supervise(ctx, "test", func(ctx context.Context, alive func()) error {
+			attempts++
+			switch {
+			case attempts <= 4:
+				return refused
+			case attempts == 5:
+				// Data arrives, then the stream drops
+				alive()
+				return nil
+			default:
+				close(waiting)
+				<-ctx.Done()
+				return ctx.Err()
+			}
+		}, func(err error) {
+			failures = append(failures, err)
+		})
+	}()
+
+	select {
+	case <-waiting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supervise stopped retrying")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supervise kept running after cancel")
+	}
+
+	// Failures stay reconnecting until downAfter, and the count starts over
+	// once a connection received data
+	want := []ConnState{
+		StateConnecting,
+		StateReconnecting, StateReconnecting, StateDown, StateDown,
+		StateConnected,
+		StateReconnecting,
+	}
+	if got := states(); !slices.Equal(got, want) {
+		t.Errorf("states = %v, want %v", got, want)
+	}
+	if len(failures) != 5 || !errors.Is(failures[4], errStreamClosed) {
+		t.Errorf("onError got %v, want 4 failures and a closed stream", failures)
+	}
+}
+
+func TestSuperviseStopsWhileWaiting(t *testing.T) {
+	saved := DefaultBackoff
+	DefaultBackoff = Backoff{Initial: time.Hour, Max: time.Hour, Factor: 2}
+	t.Cleanup(func() { DefaultBackoff = saved })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	failed := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		SuperviseUntracked(ctx, "test", func(ctx context.Context, alive func()) error {
+			return errors.New("refused")
+		}, func(error) { failed <- struct{}{} })
+	}()
+
+	<-failed
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supervise kept waiting to retry after cancel")
+	}
+}
+
+func TestSuperviseUntrackedKeepsState(t *testing.T) {
+	fastBackoff(t)
+	states := recordStates(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failures := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		SuperviseUntracked(ctx, "test", func(ctx context.Context, alive func()) error {
+			alive()
+			return errors.New("refused")
+		}, func(error) {
+			if failures++; failures > downAfter {
+				cancel()
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supervise kept running after cancel")
+	}
+	if got := states(); !slices.Equal(got, []ConnState{StateConnecting}) {
+		t.Errorf("untracked stream changed the state: %v", got)
+	}
+}
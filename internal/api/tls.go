@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"mihomoTui/internal/config"
+)
+
+// PinMismatchError reports a controller certificate that doesn't match the
+// configured fingerprint
+type PinMismatchError struct {
+	Want string
+	Got  string
+}
+
+// Error implements the error interface
+func (e *PinMismatchError) Error() string {
+	return fmt.Sprintf("certificate fingerprint %s does not match pinned %s", e.Got, e.Want)
+}
+
+// TLSConfigError reports TLS options that could not be loaded
+type TLSConfigError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *TLSConfigError) Error() string {
+	return "invalid TLS settings: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *TLSConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateTLS checks that the TLS options can be loaded, so the config page
+// can reject a missing file before saving
+func ValidateTLS(opts config.TLSConfig) error {
+	_, err := newTLSConfig(opts)
+	return err
+}
+
+// newTLSConfig builds the client TLS settings for a controller
+func newTLSConfig(opts config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, &TLSConfigError{Err: fmt.Errorf("failed to read CA file: %w", err)}
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, &TLSConfigError{Err: fmt.Errorf("no certificates found in %s", opts.CAFile)}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, &TLSConfigError{Err: fmt.Errorf("failed to load client certificate: %w", err)}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.PinnedSHA256 != "" {
+		pin, err := parseFingerprint(opts.PinnedSHA256)
+		if err != nil {
+			return nil, &TLSConfigError{Err: err}
+		}
+
+		// The pin replaces chain verification, which is what makes pinning
+		// useful for self-signed controllers. VerifyConnection still runs
+		// when InsecureSkipVerify is set.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("controller presented no certificate")
+			}
+			sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+			if !bytes.Equal(sum[:], pin) {
+				return &PinMismatchError{
+					Want: formatFingerprint(pin),
+					Got:  formatFingerprint(sum[:]),
+				}
+			}
+			return nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// parseFingerprint decodes a hex SHA-256 fingerprint, with or without the
+// colons openssl prints
+func parseFingerprint(value string) ([]byte, error) {
+	cleaned := strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(value))
+	pin, err := hex.DecodeString(cleaned)
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("pinned fingerprint must be %d hex bytes", sha256.Size)
+	}
+	return pin, nil
+}
+
+// formatFingerprint renders a fingerprint the way openssl prints it
+func formatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// IsHandshakeAlert reports whether the controller aborted the handshake,
+// typically because it requires or rejected the client certificate
+func IsHandshakeAlert(err error) bool {
+	// The standard library only reports received alerts as text
+	return err != nil && strings.Contains(err.Error(), "remote error: tls:")
+}
+
+// IsPlainHTTP reports whether an https:// address answered in plain HTTP
+func IsPlainHTTP(err error) bool {
+	var recordErr tls.RecordHeaderError
+	return errors.As(err, &recordErr) ||
+		(err != nil && strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"))
+}
+
+// isTLSError reports whether err comes from the TLS handshake
+func isTLSError(err error) bool {
+	var (
+		pinErr       *PinMismatchError
+		verifyErr    *tls.CertificateVerificationError
+		unknownCAErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		configErr    *TLSConfigError
+	)
+	return errors.As(err, &pinErr) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownCAErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &configErr) ||
+		IsPlainHTTP(err) ||
+		IsHandshakeAlert(err)
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/config"
+)
+
+// testCA issues certificates for the TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string // PEM of the CA certificate
+}
+
+// newTestCA creates a CA and writes its certificate to dir
+func newTestCA(t *testing.T, dir, name string) *testCA {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, key := createCertificate(t, template, nil, nil)
+
+	ca := &testCA{cert: cert, key: key, file: filepath.Join(dir, name+".pem")}
+	writePEM(t, ca.file, "CERTIFICATE", cert.Raw)
+	return ca
+}
+
+// issue creates a certificate signed by the CA for usage
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	cert, key := createCertificate(t, template, ca.cert, ca.key)
+	return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+}
+
+// createCertificate signs template with parent, or self-signs it
+func createCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// writePEM writes a single PEM block to path
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeKeyPair writes cert and its key to dir and returns both paths
+func writeKeyPair(t *testing.T, dir, name string, cert tls.Certificate) (certFile, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	writePEM(t, certFile, "CERTIFICATE", cert.Leaf.Raw)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// newTLSController serves /version over TLS with serverCert. clientCAs, if
+// set, makes a client certificate issued by it mandatory.
+func newTLSController(t *testing.T, serverCert tls.Certificate, clientCAs *testCA) *httptest.Server {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"meta":true,"version":"v1.19.0"}`))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	if clientCAs != nil {
+		pool := x509.NewCertPool()
+		pool.AddCert(clientCAs.cert)
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		server.TLS.ClientCAs = pool
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fingerprint returns the pin of cert as openssl prints it
+func fingerprint(cert tls.Certificate) string {
+	sum := sha256.Sum256(cert.Leaf.Raw)
+	return formatFingerprint(sum[:])
+}
+
+// getVersion asks the controller at baseURL for its version over opts
+func getVersion(baseURL string, opts config.TLSConfig) error {
+	client := NewHttpClient(config.APIConfig{BaseURL: baseURL, TLS: opts}, 5*time.Second)
+	defer client.Close()
+	_, err := client.GetVersion(context.Background())
+	return err
+}
+
+func TestTLSVerification(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	otherCA := newTestCA(t, dir, "other")
+	serverCert := ca.issue(t, "controller", x509.ExtKeyUsageServerAuth)
+	server := newTLSController(t, serverCert, nil)
+
+	wrongPin := sha256.Sum256([]byte("another certificate"))
+
+	tests := []struct {
+		name    string
+		opts    config.TLSConfig
+		wantErr bool
+	}{
+		{"trusted CA", config.TLSConfig{CAFile: ca.file}, false},
+		{"no CA", config.TLSConfig{}, true},
+		{"unknown CA", config.TLSConfig{CAFile: otherCA.file}, true},
+		{"pin", config.TLSConfig{PinnedSHA256: fingerprint(serverCert)}, false},
+		{"lowercase pin without colons", config.TLSConfig{PinnedSHA256: strings.ToLower(strings.ReplaceAll(fingerprint(serverCert), ":", ""))}, false},
+		{"wrong pin", config.TLSConfig{PinnedSHA256: hex.EncodeToString(wrongPin[:])}, true},
+		{"wrong pin despite trusted CA", config.TLSConfig{CAFile: ca.file, PinnedSHA256: hex.EncodeToString(wrongPin[:])}, true},
+		{"wrong pin with insecure skip verify", config.TLSConfig{InsecureSkipVerify: true, PinnedSHA256: hex.EncodeToString(wrongPin[:])}, true},
+		{"insecure skip verify", config.TLSConfig{InsecureSkipVerify: true}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := getVersion(server.URL, test.opts)
+			if !test.wantErr {
+				if err != nil {
+					t.Fatalf("GetVersion: %v", err)
+				}
+				return
+			}
+			if !IsKind(err, KindTLS) {
+				t.Fatalf("GetVersion = %v, want a TLS error", err)
+			}
+		})
+	}
+
+	// A pin mismatch names both fingerprints
+	err := getVersion(server.URL, config.TLSConfig{PinnedSHA256: hex.EncodeToString(wrongPin[:])})
+	var pinErr *PinMismatchError
+	if !errors.As(err, &pinErr) || pinErr.Got != fingerprint(serverCert) {
+		t.Errorf("wrong pin error = %v, want a mismatch reporting %s", err, fingerprint(serverCert))
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	server := newTLSController(t, ca.issue(t, "controller", x509.ExtKeyUsageServerAuth), ca)
+	certFile, keyFile := writeKeyPair(t, dir, "client", ca.issue(t, "client", x509.ExtKeyUsageClientAuth))
+
+	if err := getVersion(server.URL, config.TLSConfig{CAFile: ca.file, CertFile: certFile, KeyFile: keyFile}); err != nil {
+		t.Fatalf("GetVersion with a client certificate: %v", err)
+	}
+
+	// Without one the controller aborts the handshake with an alert
+	err := getVersion(server.URL, config.TLSConfig{CAFile: ca.file})
+	if !IsKind(err, KindTLS) || !IsHandshakeAlert(err) {
+		t.Errorf("GetVersion without a client certificate = %v, want a TLS handshake alert", err)
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts config.TLSConfig
+	}{
+		{"missing CA file", config.TLSConfig{CAFile: filepath.Join(dir, "missing.pem")}},
+		{"CA file without certificates", config.TLSConfig{CAFile: notPEM}},
+		{"client certificate without key", config.TLSConfig{CertFile: notPEM}},
+		{"short pin", config.TLSConfig{PinnedSHA256: "AB:CD"}},
+		{"pin that isn't hex", config.TLSConfig{PinnedSHA256: strings.Repeat("zz", sha256.Size)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var configErr *TLSConfigError
+			if err := ValidateTLS(test.opts); !errors.As(err, &configErr) {
+				t.Errorf("ValidateTLS = %v, want a TLSConfigError", err)
+			}
+			// Requests fail the same way instead of ignoring the settings
+			if err := getVersion("https://127.0.0.1:1", test.opts); !IsKind(err, KindTLS) {
+				t.Errorf("GetVersion = %v, want a TLS error", err)
+			}
+		})
+	}
+}
+
+func TestTLSPlainHTTPController(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	err := getVersion(strings.Replace(server.URL, "http://", "https://", 1), config.TLSConfig{})
+	if !IsKind(err, KindTLS) || !IsPlainHTTP(err) {
+		t.Errorf("GetVersion over https from a plain HTTP controller = %v, want a plain HTTP TLS error", err)
+	}
+	if IsHandshakeAlert(err) {
+		t.Errorf("plain HTTP answer classified as a handshake alert: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// unixPrefix marks a controller served on external-controller-unix
+	unixPrefix = "unix://"
+
+	// unixBaseURL stands in for the host part of socket requests; the
+	// transport ignores it and always dials the socket
+	unixBaseURL = "http://unix"
+)
+
+// IsUnixAddress reports whether address points at a unix socket
+func IsUnixAddress(address string) bool {
+	return strings.HasPrefix(address, unixPrefix)
+}
+
+// parseAddress splits a controller address into the base URL used to build
+// requests and, for unix:///path addresses, the socket to dial
+func parseAddress(address string) (baseURL, socketPath string) {
+	if IsUnixAddress(address) {
+		return unixBaseURL, strings.TrimPrefix(address, unixPrefix)
+	}
+	return strings.TrimRight(address, "/"), ""
+}
+
+// dialFunc opens a network connection to the controller
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// controllerDialer returns how connections to the controller are opened:
+// through the SSH tunnel, to the unix socket, or both. It returns nil when
+// the default dialer and proxy settings apply.
+func controllerDialer(socketPath string, tunnel *sshTunnel) dialFunc {
+	switch {
+	case tunnel != nil && socketPath != "":
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return tunnel.DialContext(ctx, "unix", socketPath)
+		}
+	case tunnel != nil:
+		return tunnel.DialContext
+	case socketPath != "":
+		// Ignore the placeholder host and connect to the socket instead
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	default:
+		return nil
+	}
+}
+
+// newTransport creates the HTTP transport for a controller
+func newTransport(dial dialFunc, tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if dial != nil {
+		transport.Proxy = nil
+		transport.DialContext = dial
+	}
+	return transport
+}
+
+// newWebSocketDialer creates the dialer used for streaming endpoints
+func newWebSocketDialer(dial dialFunc, tlsConfig *tls.Config) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:  tlsConfig,
+	}
+	if dial != nil {
+		dialer.Proxy = nil
+		dialer.NetDialContext = dial
+	}
+	return dialer
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mihomoTui/internal/api/apitest"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+// newUnixController serves a fake controller on a unix socket, as
+// external-controller-unix does, and returns the socket path
+func newUnixController(t *testing.T) (*apitest.Server, string) {
+	t.Helper()
+	server := apitest.NewServer()
+	t.Cleanup(server.Close)
+
+	socketPath := filepath.Join(t.TempDir(), "s.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	socketServer := &http.Server{Handler: server.Config.Handler}
+	go socketServer.Serve(listener)
+	t.Cleanup(func() { socketServer.Close() })
+	return server, socketPath
+}
+
+func TestUnixSocketController(t *testing.T) {
+	for _, transport := range []string{"websocket", "chunked"} {
+		t.Run(transport, func(t *testing.T) {
+			server, socketPath := newUnixController(t)
+			if transport == "chunked" {
+				server.DisableWebSocket()
+			}
+			client := NewHttpClient(config.APIConfig{BaseURL: "unix://" + socketPath}, 0)
+			defer client.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			version, err := client.GetVersion(ctx)
+			if err != nil || version.Version != "v1.19.0" {
+				t.Fatalf("GetVersion over the socket = %+v, %v", version, err)
+			}
+			if request, ok := server.LastRequest("GET", "/version"); !ok || request.Path != "/version" {
+				t.Errorf("socket request = %+v, %v", request, ok)
+			}
+
+			streamCtx, stop := context.WithCancel(ctx)
+			traffic := make(chan *models.Traffic, 1)
+			done := make(chan error, 1)
+			go func() { done <- client.StreamTraffic(streamCtx, func(m *models.Traffic) { traffic <- m }) }()
+			if !server.WaitSubscribers(apitest.StreamTraffic, 1, 5*time.Second) {
+				t.Fatal("stream never reached the socket")
+			}
+			server.PublishTraffic(1, 2)
+			if got := receive(t, traffic); got.Up != 1 || got.Down != 2 {
+				t.Errorf("traffic = %+v", got)
+			}
+
+			stop()
+			if err := receive(t, done); !errors.Is(err, context.Canceled) {
+				t.Errorf("stream ended with %v, want context.Canceled", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/websocket"
+)
+
+// errWebSocketUnsupported reports that the controller did not accept the upgrade
+var errWebSocketUnsupported = errors.New("websocket upgrade not supported")
+
+// websocketURL converts an endpoint into a ws:// or wss:// URL.
+// The secret is sent as ?token= as well, since that is what the
+// controller accepts from browser-style clients and what reverse
+// proxies pass through untouched.
+func (c *HttpClient) websocketURL(endpoint string) (string, error) {
+	u, err := url.Parse(c.baseURL + endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("%w: scheme %q", errWebSocketUnsupported, u.Scheme)
+	}
+
+	if c.secret != "" {
+		query := u.Query()
+		query.Set("token", c.secret)
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String(), nil
+}
+
+// upgradeRefused reports whether a failed handshake with status means the
+// upgrade itself was refused: rejected as a bad request, answered with
+// Upgrade Required, or served as a plain response
+func upgradeRefused(status int) bool {
+	switch {
+	case status == http.StatusBadRequest, status == http.StatusUpgradeRequired:
+		return true
+	case status >= 200 && status < 300:
+		return true
+	default:
+		return false
+	}
+}
+
+// streamWebSocket reads JSON messages from a WebSocket endpoint
+func (c *HttpClient) streamWebSocket(ctx context.Context, endpoint string, onMessage func([]byte)) error {
+	if c.configErr != nil {
+		return newTransportError("GET", endpoint, c.configErr)
+	}
+
+	wsURL, err := c.websocketURL(endpoint)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if c.secret != "" {
+		header.Set("Authorization", "Bearer "+c.secret)
+	}
+
+	conn, resp, err := c.wsDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			if errors.Is(err, websocket.ErrBadHandshake) && upgradeRefused(resp.StatusCode) {
+				return fmt.Errorf("%w: status %d", errWebSocketUnsupported, resp.StatusCode)
+			}
+			// A wrong secret or a missing endpoint fails the same way over
+			// either transport
+			return newStatusError(resp)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return newTransportError("GET", endpoint, err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when the caller cancels
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return err
+		}
+
+		onMessage(data)
+	}
+}
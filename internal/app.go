@@ -1,8 +1,14 @@
 package app
 
 import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
 	"mihomoTui/internal/api"
 	"mihomoTui/internal/config"
+	"mihomoTui/internal/logging"
 	"mihomoTui/internal/ui"
 	"mihomoTui/internal/ui/components"
 	"mihomoTui/internal/ui/pages"
@@ -10,6 +16,9 @@ import (
 	"github.com/rivo/tview"
 )
 
+// profilePickerModal is the overlay name used by the profile picker
+const profilePickerModal = "profiles"
+
 // App represents the main application
 type App struct {
 	// tview application
@@ -18,6 +27,15 @@ type App struct {
 	// Configuration manager
 	configManager *config.Manager
 
+	// Controller of the active profile, shared by every page
+	controller *api.ActiveController
+
+	// Core state shared by pages and components
+	store *ui.Store
+
+	// Log file, closed on exit
+	logFile io.Closer
+
 	// UI components
 	header    *components.Header
 	sidebar   *components.Sidebar
@@ -25,6 +43,7 @@ type App struct {
 	content   tview.Primitive
 
 	// Layout
+	rootPages  *tview.Pages // Main layout plus modal overlays
 	rootLayout *tview.Flex
 	mainLayout *tview.Flex
 
@@ -32,6 +51,9 @@ type App struct {
 	pages     *tview.Pages
 	pageNames []string
 
+	// Profile selected on the command line, empty for the saved one
+	startProfile string
+
 	// Current page
 	currentPage int
 
@@ -43,12 +65,14 @@ type App struct {
 	appVersion string
 }
 
-// NewApp creates a new application instance
-func NewApp(appName, appVersion string) *App {
+// NewApp creates a new application instance. A non-empty profile selects
+// the controller profile to start with.
+func NewApp(appName, appVersion, profile string) *App {
 	return &App{
 		app:            tview.NewApplication(),
-		pageNames:      []string{"dashboard", "proxies", "connections", "config", "logs"},
+		pageNames:      []string{"dashboard", "proxies", "connections", "config", "logs", "rules", "ruleproviders", "providers", "dns", "overview", "settings"},
 		focusOnSidebar: true, // Start with sidebar focused
+		startProfile:   profile,
 		appName:        appName,
 		appVersion:     appVersion,
 	}
@@ -61,12 +85,47 @@ func (a *App) Initialize() error {
 	if err := a.configManager.Load(); err != nil {
 		return err
 	}
+	if a.startProfile != "" {
+		if err := a.configManager.UseProfile(a.startProfile); err != nil {
+			return fmt.Errorf("%w (available: %s)", err, strings.Join(a.configManager.Get().ProfileNames(), ", "))
+		}
+	}
+	logFile, err := logging.Setup(a.configManager.Get().Log, a.configManager.GetDataDir())
+	if err != nil {
+		return fmt.Errorf("failed to set up logging: %w", err)
+	}
+	a.logFile = logFile
+
 	// Set application
 	ui.InitUpdater(a.app)
+	ui.ApplyTheme(a.configManager.Get().UI.Theme)
+
+	// Modals are layered above the main layout, which is filled in once
+	// the app starts
+	a.rootPages = tview.NewPages().AddPage("main", tview.NewBox(), true, true)
+	ui.Updater.SetOverlay(a.rootPages)
+	a.app.SetRoot(a.rootPages, true)
+	a.app.EnableMouse(true) // Enable mouse support by default
+
+	// Connect only once a new user told us where their core is
+	if a.configManager.FirstRun() {
+		slog.Info("No config file found, starting setup wizard")
+		pages.NewWizard(a.configManager, func(saved bool) {
+			ui.ApplyTheme(a.configManager.Get().UI.Theme)
+			a.start()
+		}).Show()
+		return nil
+	}
 
-	// Initialize API client
-	config := a.configManager.GetAPI()
-	api.InitClient(config.BaseURL, config.Secret)
+	a.start()
+	return nil
+}
+
+// start connects to the active core and builds the main layout
+func (a *App) start() {
+	// Connect to the controller of the active profile
+	a.controller = api.NewActiveController(a.configManager.GetAPI())
+	a.store = ui.NewStore(a.controller)
 
 	// Initialize UI components
 	a.setupUI()
@@ -78,16 +137,16 @@ func (a *App) Initialize() error {
 	if len(a.pageNames) > 0 {
 		a.activatePage(a.pageNames[0])
 	}
-
-	return nil
 }
 
 // setupUI initializes the user interface
 func (a *App) setupUI() {
 	// Create components
-	a.header = components.NewHeader(a.appName, a.appVersion)
+	a.header = components.NewHeader(a.controller, a.appName, a.appVersion)
 	a.sidebar = components.NewSidebar()
-	a.statusBar = components.NewStatusBar()
+	a.statusBar = components.NewStatusBar(a.store)
+	a.header.SetProfile(a.configManager.Get().Active().Name)
+	a.sidebar.SetLanguage(a.configManager.Get().UI.Language)
 
 	// Create pages
 	a.pages = tview.NewPages()
@@ -104,53 +163,61 @@ func (a *App) setupUI() {
 	// Create layouts
 	a.setupLayouts()
 
-	// Configure application
-	a.app.SetRoot(a.rootLayout, true)
-	a.app.EnableMouse(true) // Enable mouse support by default
+	// Replace the placeholder, keeping it below any open modal
+	a.rootPages.AddPage("main", a.rootLayout, true, true)
+	a.rootPages.SendToBack("main")
 
 	// Set global key handlers
 	a.app.SetInputCapture(a.handleGlobalKeys)
 
 	// Set initial focus to sidebar
 	a.setFocus(true)
-
-	// Set StatusBar reference in UI Updater
-	ui.Updater.SetStatusBar(a.statusBar)
 }
 
 // setupPages initializes all pages
 func (a *App) setupPages() {
-	// Dashboard page
-	dashboardPage := pages.NewDashboard()
-	dashboardPage.SetInputCapture(dashboardPage.GetInputCapture())
-	a.pages.AddPage("dashboard", dashboardPage, true, true)
-
-	// Proxies page
-	proxiesPage := pages.NewProxies()
-	a.pages.AddPage("proxies", proxiesPage, true, false)
-
-	// Connections page
-	connectionsPage := pages.NewConnections()
-	a.pages.AddPage("connections", connectionsPage, true, false)
-
-	// Config page
-	configPage := pages.NewConfig(a.configManager)
-	a.pages.AddPage("config", configPage, true, false)
-
-	// Logs page
-	logsPage := pages.NewLogs()
-	a.pages.AddPage("logs", logsPage, true, false)
+	for i, name := range a.pageNames {
+		a.pages.AddPage(name, a.newPage(name), true, i == 0)
+	}
+}
 
-	// Settings page
-	// settingsPage := pages.NewSettings(a.configManager)
-	// a.pages.AddPage("settings", settingsPage, true, false)
+// newPage creates the page registered under name
+func (a *App) newPage(name string) tview.Primitive {
+	switch name {
+	case "dashboard":
+		dashboardPage := pages.NewDashboard(a.controller, a.store)
+		dashboardPage.SetInputCapture(dashboardPage.GetInputCapture())
+		return dashboardPage
+	case "proxies":
+		return pages.NewProxies(a.controller, a.store)
+	case "connections":
+		return pages.NewConnections(a.controller, a.store)
+	case "config":
+		return pages.NewConfig(a.configManager, a.applyProfile)
+	case "logs":
+		return pages.NewLogs(a.controller)
+	case "rules":
+		return pages.NewRules(a.controller)
+	case "ruleproviders":
+		return pages.NewRuleProviders(a.controller)
+	case "providers":
+		return pages.NewProviders(a.controller)
+	case "dns":
+		return pages.NewDNS(a.controller)
+	case "overview":
+		return pages.NewOverview(a.configManager, a.openProfile)
+	case "settings":
+		return pages.NewSettings(a.configManager, a.applyUISettings, a.runWizard)
+	default:
+		return tview.NewBox()
+	}
 }
 
 // setupLayouts creates the application layout
 func (a *App) setupLayouts() {
 	// Main layout (sidebar + content)
 	a.mainLayout = tview.NewFlex().
-		AddItem(a.sidebar, 12, 0, true).
+		AddItem(a.sidebar, 14, 0, true).
 		AddItem(a.content, 0, 1, false)
 
 	// Root layout (header + main + status)
@@ -169,6 +236,106 @@ func (a *App) basicInitData() {
 	go a.statusBar.Active()
 }
 
+// showProfilePicker lets the user switch to another controller profile
+func (a *App) showProfilePicker() {
+	cfg := a.configManager.Get()
+	active := cfg.Active().Name
+	ui.Updater.ShowPicker(profilePickerModal, "切换配置", cfg.ProfileNames(), active, func(name string) {
+		if name == active {
+			return
+		}
+		if err := a.configManager.SetActiveProfile(name); err != nil {
+			slog.Warn("Failed to switch profile", "profile", name, "err", err)
+			return
+		}
+		a.applyProfile()
+
+		// The config page survives a restart, so reload its form
+		if a.pageNames[a.currentPage] == "config" {
+			a.activatePage("config")
+		}
+	})
+}
+
+// runWizard runs the setup wizard again for the active profile and applies
+// what it saved
+func (a *App) runWizard() {
+	before := *a.configManager.Get().Active()
+	pages.NewWizard(a.configManager, func(saved bool) {
+		if !saved {
+			return
+		}
+		a.applyUISettings()
+		if *a.configManager.Get().Active() != before {
+			a.applyProfile()
+		}
+	}).Show()
+}
+
+// applyUISettings applies the saved appearance settings. The theme only
+// reaches primitives created from now on.
+func (a *App) applyUISettings() {
+	cfg := a.configManager.Get().UI
+	ui.ApplyTheme(cfg.Theme)
+	a.sidebar.SetLanguage(cfg.Language)
+}
+
+// openProfile makes name the active profile and shows its dashboard
+func (a *App) openProfile(name string) {
+	if name == a.configManager.Get().Active().Name {
+		a.switchPage(0)
+		return
+	}
+	if err := a.configManager.SetActiveProfile(name); err != nil {
+		slog.Warn("Failed to switch profile", "profile", name, "err", err)
+		return
+	}
+
+	// Leave the current page first, so the restart activates the
+	// dashboard instead of the page being left
+	a.deactivatePage(a.pageNames[a.currentPage])
+	a.currentPage = 0
+	a.pages.SwitchToPage(a.pageNames[0])
+	a.sidebar.SelectItem(0)
+
+	a.applyProfile()
+	a.setFocus(false)
+}
+
+// applyProfile reconnects to the core of the active profile and restarts
+// every stream and page, so nothing from the previous core lingers.
+// Must be called from the UI goroutine.
+func (a *App) applyProfile() {
+	profile := a.configManager.Get().Active()
+	slog.Info("Applying profile", "profile", profile.Name)
+
+	// Stop the streams of the old core before the client moves on
+	a.statusBar.Deactivate()
+	currentPageName := a.pageNames[a.currentPage]
+	a.deactivatePage(currentPageName)
+
+	a.controller.Switch(profile.API)
+	a.store.Reset()
+	a.header.SetProfile(profile.Name)
+
+	// Rebuild the pages that hold data from the core. The config page
+	// has none and keeps the status of the change it just made.
+	for _, name := range a.pageNames {
+		if name == "config" {
+			continue
+		}
+		a.pages.RemovePage(name)
+		a.pages.AddPage(name, a.newPage(name), true, false)
+	}
+	a.pages.SwitchToPage(currentPageName)
+	if currentPageName != "config" {
+		a.activatePage(currentPageName)
+	}
+	a.setFocus(a.focusOnSidebar)
+
+	go a.statusBar.Active()
+}
+
 // switchPage switches to a specific page
 func (a *App) switchPage(index int) {
 	if index >= 0 && index < len(a.pageNames) {
@@ -242,4 +409,10 @@ func (a *App) Stop() {
 		}
 	}
 
+	if a.controller != nil {
+		a.controller.Close()
+	}
+	if a.logFile != nil {
+		a.logFile.Close()
+	}
 }
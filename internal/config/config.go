@@ -3,23 +3,55 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // AppConfig represents the application configuration
 type AppConfig struct {
-	// API settings
-	API APIConfig `json:"api"`
+	// Controller profiles, one per core
+	Profiles      []Profile `json:"profiles"`
+	ActiveProfile string    `json:"active_profile"`
+
+	// Appearance of the interface
+	UI UIConfig `json:"ui"`
+
+	// Log file settings
+	Log LogConfig `json:"log"`
+
+	// Single controller of config files written before profiles existed.
+	// It is moved into a profile on load and never written back.
+	LegacyAPI *APIConfig `json:"api,omitempty"`
 }
 
 // GetValue returns configuration value by label
 func (c *AppConfig) GetValue(label string) string {
+	profile := c.Active()
 	switch label {
+	case "配置名称":
+		return profile.Name
 	case "API地址":
-		return c.API.BaseURL
+		return profile.API.BaseURL
 	case "API密钥":
-		return c.API.Secret
+		return profile.API.Secret
+	case "CA证书":
+		return profile.API.TLS.CAFile
+	case "客户端证书":
+		return profile.API.TLS.CertFile
+	case "客户端私钥":
+		return profile.API.TLS.KeyFile
+	case "证书指纹":
+		return profile.API.TLS.PinnedSHA256
+	case "SSH主机":
+		return profile.API.SSH.Host
+	case "SSH用户":
+		return profile.API.SSH.User
+	case "SSH密钥":
+		return profile.API.SSH.KeyFile
+	case "known_hosts":
+		return profile.API.SSH.KnownHostsFile
 	default:
 		return ""
 	}
@@ -27,11 +59,31 @@ func (c *AppConfig) GetValue(label string) string {
 
 // SetValue sets configuration value by label
 func (c *AppConfig) SetValue(label, value string) {
+	profile := c.Active()
 	switch label {
+	case "配置名称":
+		profile.Name = value
+		c.ActiveProfile = value
 	case "API地址":
-		c.API.BaseURL = value
+		profile.API.BaseURL = value
 	case "API密钥":
-		c.API.Secret = value
+		profile.API.Secret = value
+	case "CA证书":
+		profile.API.TLS.CAFile = value
+	case "客户端证书":
+		profile.API.TLS.CertFile = value
+	case "客户端私钥":
+		profile.API.TLS.KeyFile = value
+	case "证书指纹":
+		profile.API.TLS.PinnedSHA256 = value
+	case "SSH主机":
+		profile.API.SSH.Host = value
+	case "SSH用户":
+		profile.API.SSH.User = value
+	case "SSH密钥":
+		profile.API.SSH.KeyFile = value
+	case "known_hosts":
+		profile.API.SSH.KnownHostsFile = value
 	default:
 		return
 	}
@@ -39,17 +91,57 @@ func (c *AppConfig) SetValue(label, value string) {
 
 // APIConfig represents API configuration
 type APIConfig struct {
-	BaseURL string `json:"base_url"`
-	Secret  string `json:"secret"`
+	BaseURL string    `json:"base_url"`
+	Secret  string    `json:"secret"`
+	TLS     TLSConfig `json:"tls"`
+	SSH     SSHConfig `json:"ssh"`
+}
+
+// TLSConfig holds TLS options for controllers served over https
+type TLSConfig struct {
+	CAFile   string `json:"ca_file,omitempty"`   // PEM bundle trusted in addition to the system roots
+	CertFile string `json:"cert_file,omitempty"` // Client certificate for mutual TLS
+	KeyFile  string `json:"key_file,omitempty"`  // Private key for CertFile
+
+	// SHA-256 fingerprint of the controller certificate, as printed by
+	// openssl x509 -fingerprint -sha256. A matching certificate is trusted
+	// even when it is self-signed.
+	PinnedSHA256 string `json:"pinned_sha256,omitempty"`
+
+	// Skip certificate verification entirely; for lab machines only
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// UIConfig holds the appearance settings chosen in the setup wizard or on
+// the settings page. Empty values select the defaults.
+type UIConfig struct {
+	Language string `json:"language,omitempty"` // Language of the navigation, see ui.Languages
+	Theme    string `json:"theme,omitempty"`    // Color theme, see ui.Themes
+}
+
+// LogConfig controls the log file. Empty values select the defaults.
+type LogConfig struct {
+	Path       string `json:"path,omitempty"`        // Defaults to mihomoTui.log in the data directory
+	Level      string `json:"level,omitempty"`       // debug, info, warn or error; info by default
+	MaxSizeMB  int    `json:"max_size_mb,omitempty"` // Size at which the file is rotated, 10 MB by default
+	MaxBackups int    `json:"max_backups,omitempty"` // Rotated files kept, 3 by default
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *AppConfig {
 	return &AppConfig{
-		API: APIConfig{
-			BaseURL: "http://127.0.0.1:9090",
-			Secret:  "",
+		Profiles: []Profile{
+			{Name: DefaultProfileName, API: DefaultAPIConfig()},
 		},
+		ActiveProfile: DefaultProfileName,
+	}
+}
+
+// DefaultAPIConfig returns the settings of a core on this machine
+func DefaultAPIConfig() APIConfig {
+	return APIConfig{
+		BaseURL: "http://127.0.0.1:9090",
+		Secret:  "",
 	}
 }
 
@@ -57,6 +149,10 @@ func DefaultConfig() *AppConfig {
 type Manager struct {
 	config     *AppConfig
 	configPath string
+	firstRun   bool // Load found no config file and created one
+
+	// Active profile kept in the file while UseProfile overrides it
+	savedProfile string
 }
 
 // NewManager creates a new configuration manager
@@ -81,6 +177,7 @@ func (m *Manager) Load() error {
 	// Check if config file exists
 	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
 		// Config file doesn't exist, create with default values
+		m.firstRun = true
 		return m.Save()
 	}
 
@@ -91,13 +188,26 @@ func (m *Manager) Load() error {
 	}
 
 	// Parse JSON
-	if err := json.Unmarshal(data, m.config); err != nil {
+	var config AppConfig
+	if err := json.Unmarshal(data, &config); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
+	m.config = &config
+
+	// Rewrite older files in the profile layout
+	if config.normalize() {
+		return m.Save()
+	}
 
 	return nil
 }
 
+// FirstRun reports whether Load created the config file, meaning the
+// user has not set anything up yet
+func (m *Manager) FirstRun() bool {
+	return m.firstRun
+}
+
 // Save saves configuration to file
 func (m *Manager) Save() error {
 	// Create config directory if it doesn't exist
@@ -105,8 +215,16 @@ func (m *Manager) Save() error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	// A profile picked for this run only doesn't become the default
+	config := m.config
+	if m.savedProfile != "" && config.Profile(m.savedProfile) != nil {
+		saved := *config
+		saved.ActiveProfile = m.savedProfile
+		config = &saved
+	}
+
 	// Marshal to JSON
-	data, err := json.MarshalIndent(m.config, "", "  ")
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -129,14 +247,14 @@ func (m *Manager) Set(config *AppConfig) {
 	m.config = config
 }
 
-// GetAPI returns API configuration
+// GetAPI returns the API configuration of the active profile
 func (m *Manager) GetAPI() APIConfig {
-	return m.config.API
+	return m.config.Active().API
 }
 
-// SetAPI updates API configuration
+// SetAPI updates the API configuration of the active profile
 func (m *Manager) SetAPI(config APIConfig) error {
-	m.config.API = config
+	m.config.Active().API = config
 	return m.Save()
 }
 
@@ -191,20 +309,67 @@ func (m *Manager) Restore() error {
 	}
 
 	// Update current config
+	config.normalize()
 	m.config = &config
 
 	// Save restored config
 	return m.Save()
 }
 
+// SSHConfig describes an SSH server the controller is reached through. The
+// controller address is then dialled from that server, so 127.0.0.1 refers
+// to the remote host.
+type SSHConfig struct {
+	Host           string `json:"host,omitempty"`             // host or host:port, empty disables the tunnel
+	User           string `json:"user,omitempty"`             // Defaults to the local user name
+	KeyFile        string `json:"key_file,omitempty"`         // Private key; ssh-agent is used when empty
+	KnownHostsFile string `json:"known_hosts_file,omitempty"` // Defaults to ~/.ssh/known_hosts
+}
+
+// Enabled reports whether the controller is reached through SSH
+func (s SSHConfig) Enabled() bool {
+	return s.Host != ""
+}
+
 // Validate validates the current configuration
 func (m *Manager) Validate() error {
 	config := m.config
 
-	// Validate API config
-	if config.API.BaseURL == "" {
+	seen := make(map[string]bool, len(config.Profiles))
+	for _, profile := range config.Profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("profile name cannot be empty")
+		}
+		if seen[profile.Name] {
+			return fmt.Errorf("duplicate profile name %q", profile.Name)
+		}
+		seen[profile.Name] = true
+
+		if err := ValidateAPI(profile.API); err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateAPI validates the controller settings of a profile
+func ValidateAPI(api APIConfig) error {
+	if api.BaseURL == "" {
 		return fmt.Errorf("API base URL cannot be empty")
 	}
+	if socketPath, ok := strings.CutPrefix(api.BaseURL, "unix://"); ok {
+		if socketPath == "" {
+			return fmt.Errorf("unix socket path cannot be empty")
+		}
+	} else if u, err := url.Parse(api.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("API base URL must be http://, https:// or unix:// address")
+	}
+
+	tls := api.TLS
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf("client certificate and key must be set together")
+	}
 
 	return nil
 }
@@ -236,6 +401,7 @@ func (m *Manager) Import(path string) error {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return fmt.Errorf("failed to parse import file: %w", err)
 	}
+	config.normalize()
 
 	// Create temporary manager to validate
 	tempManager := &Manager{config: &config}
@@ -250,15 +416,17 @@ func (m *Manager) Import(path string) error {
 	return m.Save()
 }
 
-// GetEndpoint returns the full API endpoint with secret
+// GetEndpoint returns the API endpoint and secret of the active profile
 func (m *Manager) GetEndpoint() (string, string) {
-	return m.config.API.BaseURL, m.config.API.Secret
+	api := m.config.Active().API
+	return api.BaseURL, api.Secret
 }
 
-// SetEndpoint updates API endpoint and secret
+// SetEndpoint updates the API endpoint and secret of the active profile
 func (m *Manager) SetEndpoint(baseURL, secret string) error {
-	m.config.API.BaseURL = baseURL
-	m.config.API.Secret = secret
+	api := &m.config.Active().API
+	api.BaseURL = baseURL
+	api.Secret = secret
 	return m.Save()
 }
 
@@ -0,0 +1,135 @@
+package config
+
+import "fmt"
+
+// DefaultProfileName names the profile created for a fresh or migrated config
+const DefaultProfileName = "default"
+
+// Profile is a named controller, such as a home router or a laptop core
+type Profile struct {
+	Name string    `json:"name"`
+	API  APIConfig `json:"api"`
+}
+
+// Active returns the profile in use, falling back to the first one
+func (c *AppConfig) Active() *Profile {
+	if profile := c.Profile(c.ActiveProfile); profile != nil {
+		return profile
+	}
+	return &c.Profiles[0]
+}
+
+// Profile returns the profile with the given name, or nil
+func (c *AppConfig) Profile(name string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ProfileNames returns the profile names in display order
+func (c *AppConfig) ProfileNames() []string {
+	names := make([]string, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// Clone returns a copy that can be edited without touching c
+func (c *AppConfig) Clone() *AppConfig {
+	clone := *c
+	clone.Profiles = append([]Profile(nil), c.Profiles...)
+	return &clone
+}
+
+// normalize migrates the single controller of older files into a profile
+// and makes sure an existing profile is active. It reports whether
+// anything changed.
+func (c *AppConfig) normalize() bool {
+	changed := false
+
+	if c.LegacyAPI != nil {
+		if len(c.Profiles) == 0 {
+			c.Profiles = []Profile{{Name: DefaultProfileName, API: *c.LegacyAPI}}
+		}
+		c.LegacyAPI = nil
+		changed = true
+	}
+
+	if len(c.Profiles) == 0 {
+		c.Profiles = []Profile{{Name: DefaultProfileName, API: DefaultAPIConfig()}}
+		changed = true
+	}
+
+	if c.Profile(c.ActiveProfile) == nil {
+		c.ActiveProfile = c.Profiles[0].Name
+		changed = true
+	}
+
+	return changed
+}
+
+// SetActiveProfile switches to the named profile and saves the choice
+func (m *Manager) SetActiveProfile(name string) error {
+	if m.config.Profile(name) == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	m.config.ActiveProfile = name
+	m.savedProfile = ""
+	return m.Save()
+}
+
+// UseProfile switches to the named profile for this run only. Saving keeps
+// the previous choice as the default until SetActiveProfile is called.
+func (m *Manager) UseProfile(name string) error {
+	if m.config.Profile(name) == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+
+	if m.savedProfile == "" {
+		m.savedProfile = m.config.ActiveProfile
+	}
+	m.config.ActiveProfile = name
+	return nil
+}
+
+// AddProfile creates a profile for a local core and makes it active
+func (m *Manager) AddProfile(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if m.config.Profile(name) != nil {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	m.config.Profiles = append(m.config.Profiles, Profile{Name: name, API: DefaultAPIConfig()})
+	m.config.ActiveProfile = name
+	m.savedProfile = ""
+	return m.Save()
+}
+
+// RemoveProfile deletes the named profile. The last profile can't be
+// removed; removing the active one activates the first remaining profile.
+func (m *Manager) RemoveProfile(name string) error {
+	if m.config.Profile(name) == nil {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	if len(m.config.Profiles) == 1 {
+		return fmt.Errorf("cannot remove the only profile")
+	}
+
+	profiles := make([]Profile, 0, len(m.config.Profiles)-1)
+	for _, profile := range m.config.Profiles {
+		if profile.Name != name {
+			profiles = append(profiles, profile)
+		}
+	}
+	m.config.Profiles = profiles
+	m.config.normalize()
+
+	return m.Save()
+}
@@ -0,0 +1,216 @@
+// Package discovery finds mihomo and Clash controllers on this machine, so
+// new users don't have to look up the controller address and secret.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// probeTimeout bounds the version request sent to each candidate
+const probeTimeout = 2 * time.Second
+
+// commonPorts are the controller ports used by default by mihomo, Clash
+// and the popular GUI clients
+var commonPorts = []int{9090, 9097, 9091}
+
+// PortScan is the source of candidates found by probing commonPorts
+const PortScan = "port scan"
+
+// Candidate is a controller found on this machine
+type Candidate struct {
+	API     config.APIConfig
+	Source  string // Config file the address came from, or PortScan
+	Version string // Core version, empty when the probe failed
+	Err     error  // Why the probe failed, nil when the core answered
+}
+
+// Reachable reports whether the core answered with the known secret
+func (c Candidate) Reachable() bool {
+	return c.Err == nil
+}
+
+// coreConfig holds the controller settings of a mihomo or Clash config
+type coreConfig struct {
+	ExternalController     string `yaml:"external-controller"`
+	ExternalControllerTLS  string `yaml:"external-controller-tls"`
+	ExternalControllerUnix string `yaml:"external-controller-unix"`
+	Secret                 string `yaml:"secret"`
+}
+
+// ConfigPaths returns the well-known locations of core config files
+func ConfigPaths() []string {
+	var dirs []string
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, configDir)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".config"))
+	}
+	dirs = append(dirs, "/etc")
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		for _, name := range []string{"mihomo", "clash.meta", "clash"} {
+			path := filepath.Join(dir, name, "config.yaml")
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// Discover reads the core configs in ConfigPaths and probes commonPorts on
+// localhost, and returns the controllers found. Reachable candidates come
+// first. Configured controllers are kept even when the core is not running;
+// ports nothing listens on are dropped.
+func Discover(ctx context.Context) []Candidate {
+	return discover(ctx, ConfigPaths(), commonPorts)
+}
+
+func discover(ctx context.Context, paths []string, ports []int) []Candidate {
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	add := func(candidate Candidate) {
+		if !seen[candidate.API.BaseURL] {
+			seen[candidate.API.BaseURL] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, path := range paths {
+		found, err := ReadConfig(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				slog.Debug("Discovery skipped config", "path", path, "err", err)
+			}
+			continue
+		}
+		for _, candidate := range found {
+			add(candidate)
+		}
+	}
+	for _, port := range ports {
+		add(Candidate{
+			API:    config.APIConfig{BaseURL: "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port))},
+			Source: PortScan,
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probe(ctx, &candidates[i])
+		}()
+	}
+	wg.Wait()
+
+	// A scanned port only counts when something that speaks the API answered
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if candidate.Source != PortScan || candidate.Reachable() || api.IsKind(candidate.Err, api.KindUnauthorized) {
+			kept = append(kept, candidate)
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return rank(kept[i]) < rank(kept[j])
+	})
+	return kept
+}
+
+// ReadConfig extracts the controllers declared in a mihomo or Clash config
+// file. Listen addresses on every interface are reached through localhost.
+func ReadConfig(path string) ([]Candidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg coreConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var candidates []Candidate
+	add := func(baseURL string) {
+		candidates = append(candidates, Candidate{
+			API:    config.APIConfig{BaseURL: baseURL, Secret: cfg.Secret},
+			Source: path,
+		})
+	}
+
+	if socketPath := strings.TrimSpace(cfg.ExternalControllerUnix); socketPath != "" {
+		// mihomo resolves relative paths against its home directory, which
+		// is the one holding the config
+		if !filepath.IsAbs(socketPath) {
+			socketPath = filepath.Join(filepath.Dir(path), socketPath)
+		}
+		add("unix://" + socketPath)
+	}
+	if baseURL, ok := controllerURL("http", cfg.ExternalController); ok {
+		add(baseURL)
+	}
+	if baseURL, ok := controllerURL("https", cfg.ExternalControllerTLS); ok {
+		add(baseURL)
+	}
+
+	return candidates, nil
+}
+
+// controllerURL turns a listen address such as ":9090" or "0.0.0.0:9090"
+// into the URL to connect to
+func controllerURL(scheme, addr string) (string, bool) {
+	host, port, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil || port == "" {
+		return "", false
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port), true
+}
+
+// probe asks the candidate for its version
+func probe(ctx context.Context, candidate *Candidate) {
+	client := api.NewHttpClient(candidate.API, probeTimeout)
+	defer client.Close()
+
+	version, err := client.GetVersion(ctx)
+	if err != nil {
+		candidate.Err = err
+		return
+	}
+	candidate.Version = version.Version
+}
+
+// rank orders reachable cores first, then cores that need another secret
+func rank(candidate Candidate) int {
+	switch {
+	case candidate.Reachable():
+		return 0
+	case api.IsKind(candidate.Err, api.KindUnauthorized):
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,117 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	path := writeConfig(t, `
+mixed-port: 7890
+external-controller: 0.0.0.0:9090
+external-controller-tls: 192.168.1.2:9443
+external-controller-unix: mihomo.sock
+secret: "s3cret"
+proxies:
+  - name: direct
+    type: direct
+`)
+
+	candidates, err := ReadConfig(path)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+
+	want := []string{
+		"unix://" + filepath.Join(filepath.Dir(path), "mihomo.sock"),
+		"http://127.0.0.1:9090",
+		"https://192.168.1.2:9443",
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("got %d candidates, want %d: %+v", len(candidates), len(want), candidates)
+	}
+	for i, candidate := range candidates {
+		if candidate.API.BaseURL != want[i] {
+			t.Errorf("candidate %d = %q, want %q", i, candidate.API.BaseURL, want[i])
+		}
+		if candidate.API.Secret != "s3cret" || candidate.Source != path {
+			t.Errorf("candidate %d = %+v, want secret and source from the config", i, candidate)
+		}
+	}
+}
+
+func TestControllerURL(t *testing.T) {
+	tests := map[string]string{
+		":9090":          "http://127.0.0.1:9090",
+		"[::]:9090":      "http://127.0.0.1:9090",
+		"127.0.0.1:9097": "http://127.0.0.1:9097",
+		"[::1]:9090":     "http://[::1]:9090",
+		"router:9090":    "http://router:9090",
+		"":               "",
+		"9090":           "",
+	}
+	for addr, want := range tests {
+		got, ok := controllerURL("http", addr)
+		if got != want || ok != (want != "") {
+			t.Errorf("controllerURL(%q) = %q, %v, want %q", addr, got, ok, want)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"version": "v1.19.0", "meta": true})
+	}))
+	defer core.Close()
+
+	_, port, _ := net.SplitHostPort(core.Listener.Addr().String())
+	corePort, _ := strconv.Atoi(port)
+
+	// A port nothing listens on
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedPort := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	configured := writeConfig(t, "external-controller: 127.0.0.1:"+strconv.Itoa(closedPort)+"\nsecret: s3cret\n")
+	reachable := writeConfig(t, "external-controller: :"+port+"\nsecret: s3cret\n")
+
+	candidates := discover(context.Background(), []string{configured, reachable, "/nonexistent/config.yaml"}, []int{corePort, closedPort})
+
+	// The scanned core port is already known from the config, and the
+	// closed port is only kept because a config declares it
+	if len(candidates) != 2 {
+		t.Fatalf("got %d candidates, want 2: %+v", len(candidates), candidates)
+	}
+	if first := candidates[0]; !first.Reachable() || first.Version != "v1.19.0" || first.Source != reachable {
+		t.Errorf("first candidate = %+v, want the reachable core", first)
+	}
+	if second := candidates[1]; second.Reachable() || second.Source != configured {
+		t.Errorf("second candidate = %+v, want the configured but stopped core", second)
+	}
+
+	// Without the secret a scanned core is still offered
+	candidates = discover(context.Background(), nil, []int{corePort, closedPort})
+	if len(candidates) != 1 || candidates[0].Source != PortScan || candidates[0].Reachable() {
+		t.Errorf("candidates = %+v, want the scanned core needing a secret", candidates)
+	}
+}
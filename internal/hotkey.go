@@ -1,9 +1,22 @@
 package app
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"mihomoTui/internal/ui"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 // handleGlobalKeys handles global keyboard shortcuts
 func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
+	// Leave keys to an open modal, except for quitting
+	if ui.Updater.HasModal() {
+		if event.Key() == tcell.KeyCtrlC {
+			a.Stop()
+			return nil
+		}
+		return event
+	}
+
 	// Handle Escape key to return to sidebar (only when not on sidebar)
 	if event.Key() == tcell.KeyEscape && !a.focusOnSidebar {
 		a.setFocus(true) // Switch to sidebar
@@ -15,6 +28,9 @@ func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
 	case tcell.KeyCtrlC:
 		a.Stop()
 		return nil
+	case tcell.KeyCtrlP:
+		a.showProfilePicker()
+		return nil
 	case tcell.KeyF1:
 		a.switchPage(0) // Dashboard
 		return nil
@@ -30,9 +46,24 @@ func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
 	case tcell.KeyF5:
 		a.switchPage(4) // Logs
 		return nil
-		// case tcell.KeyF6:
-		// 	a.switchPage(5) // Settings
-		// 	return nil
+	case tcell.KeyF6:
+		a.switchPage(5) // Rules
+		return nil
+	case tcell.KeyF7:
+		a.switchPage(6) // Rule providers
+		return nil
+	case tcell.KeyF8:
+		a.switchPage(7) // Proxy providers
+		return nil
+	case tcell.KeyF9:
+		a.switchPage(8) // DNS
+		return nil
+	case tcell.KeyF10:
+		a.switchPage(9) // Overview
+		return nil
+	case tcell.KeyF11:
+		a.switchPage(10) // Settings
+		return nil
 	}
 
 	// Handle Ctrl + number keys
@@ -53,9 +84,21 @@ func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
 		case '5':
 			a.switchPage(4) // Ctrl+5: Logs
 			return nil
-		// case '6':
-		// 	a.switchPage(5) // Ctrl+6: Settings
-		// 	return nil
+		case '6':
+			a.switchPage(5) // Ctrl+6: Rules
+			return nil
+		case '7':
+			a.switchPage(6) // Ctrl+7: Rule providers
+			return nil
+		case '8':
+			a.switchPage(7) // Ctrl+8: Proxy providers
+			return nil
+		case '9':
+			a.switchPage(8) // Ctrl+9: DNS
+			return nil
+		case '0':
+			a.switchPage(9) // Ctrl+0: Overview
+			return nil
 		case 'q', 'Q':
 			a.Stop() // Ctrl+Q: Quit
 			return nil
@@ -80,9 +123,24 @@ func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
 		case 'l', 'L':
 			a.switchPage(4) // Alt+L: Logs
 			return nil
-			// case 's', 'S':
-			// 	a.switchPage(5) // Alt+S: Settings
-			// 	return nil
+		case 'u', 'U':
+			a.switchPage(5) // Alt+U: Rules
+			return nil
+		case 'g', 'G':
+			a.switchPage(6) // Alt+G: Rule providers
+			return nil
+		case 'b', 'B':
+			a.switchPage(7) // Alt+B: Proxy providers
+			return nil
+		case 'n', 'N':
+			a.switchPage(8) // Alt+N: DNS
+			return nil
+		case 'o', 'O':
+			a.switchPage(9) // Alt+O: Overview
+			return nil
+		case 's', 'S':
+			a.switchPage(10) // Alt+S: Settings
+			return nil
 		}
 	}
 
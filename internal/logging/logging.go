@@ -0,0 +1,114 @@
+// Package logging sets up the application log: a rotated file with level
+// filtering and structured fields, from which secrets are redacted. Code
+// logs through log/slog; output of the standard log package ends up in
+// the same file.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mihomoTui/internal/config"
+)
+
+// Defaults for empty LogConfig fields
+const (
+	defaultFileName   = "mihomoTui.log"
+	defaultMaxSizeMB  = 10
+	defaultMaxBackups = 3
+)
+
+// level filters every handler created by Setup, so it can change while
+// the application runs
+var level = new(slog.LevelVar)
+
+// path is the file opened by Setup
+var path string
+
+// Setup makes the file described by cfg the destination of slog and the
+// standard log package. A relative or empty path is placed in dataDir.
+// Close the returned file when the application exits.
+func Setup(cfg config.LogConfig, dataDir string) (io.Closer, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+
+	logPath := cfg.Path
+	if logPath == "" {
+		logPath = defaultFileName
+	}
+	if !filepath.IsAbs(logPath) {
+		logPath = filepath.Join(dataDir, logPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	maxSize := cfg.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = defaultMaxSizeMB
+	}
+	maxBackups := cfg.MaxBackups
+	if maxBackups <= 0 {
+		maxBackups = defaultMaxBackups
+	}
+
+	file, err := openRotatingFile(logPath, int64(maxSize)<<20, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	path = logPath
+	slog.SetDefault(slog.New(NewHandler(file, level)))
+	return file, nil
+}
+
+// Path returns the log file opened by Setup, empty before
+func Path() string {
+	return path
+}
+
+// SetLevel changes the level of the log set up by Setup
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// NewHandler returns a text handler writing records of at least level to
+// w, with secrets redacted
+func NewHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return &redactingHandler{
+		next: slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: redactAttr,
+		}),
+	}
+}
+
+// Levels lists the level names accepted in LogConfig, most verbose first
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel parses a level name as used in LogConfig. An empty name is
+// info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+	}
+}
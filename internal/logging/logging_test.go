@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	AddSecret("hunter2")
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, slog.LevelDebug))
+	logger.Info("connecting with hunter2",
+		"secret", "anything",
+		"Authorization", "Bearer abc",
+		"url", "ws://127.0.0.1:9090/traffic?token=abc&x=1",
+		"err", errors.New(`dial "ws://host/logs?token=hunter2": refused`),
+		"profile", "home",
+	)
+
+	out := buf.String()
+	for _, leak := range []string{"hunter2", "anything", "Bearer abc", "token=abc"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("log contains %q: %s", leak, out)
+		}
+	}
+	if !strings.Contains(out, "profile=home") || !strings.Contains(out, "x=1") {
+		t.Errorf("log lost harmless fields: %s", out)
+	}
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	level.Set(slog.LevelWarn)
+	logger := slog.New(NewHandler(&buf, &level))
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("warn level output = %q", out)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+	if parsed, err := ParseLevel(""); err != nil || parsed != slog.LevelInfo {
+		t.Errorf("ParseLevel(\"\") = %v, %v, want info", parsed, err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := openRotatingFile(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	line := []byte(strings.Repeat("x", 59) + "\n")
+	for range 5 {
+		if _, err := file.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each file holds one line; the oldest of the five are gone
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("missing %s: %v", filepath.Base(name), err)
+		}
+		if info.Size() != int64(len(line)) {
+			t.Errorf("%s has %d bytes, want %d", filepath.Base(name), info.Size(), len(line))
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("kept more backups than configured: %v", err)
+	}
+}
+
+func TestRotationKeepsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("earlier run\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := openRotatingFile(path, 1<<20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("this run\n"))
+	file.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "earlier run\nthis run\n" {
+		t.Errorf("log file = %q, want the earlier run kept", data)
+	}
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces secrets in the log
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"secret":        true,
+	"token":         true,
+	"password":      true,
+	"passphrase":    true,
+	"authorization": true,
+}
+
+// tokenParam matches the secret passed in WebSocket URLs, which shows up in
+// dial errors
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s"']+`)
+
+// secrets holds the values registered with AddSecret
+var secrets = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+// AddSecret makes every later log record replace value wherever it
+// appears, in the message or in an attribute
+func AddSecret(value string) {
+	if value == "" {
+		return
+	}
+	secrets.Lock()
+	defer secrets.Unlock()
+	secrets.values[value] = struct{}{}
+}
+
+// Redact returns s with registered secrets and URL tokens replaced
+func Redact(s string) string {
+	secrets.RLock()
+	for value := range secrets.values {
+		s = strings.ReplaceAll(s, value, redacted)
+	}
+	secrets.RUnlock()
+
+	return tokenParam.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// redactAttr hides the values of sensitive keys and scrubs the rest
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(Redact(attr.Value.String()))
+	case slog.KindAny:
+		// Errors and other values are formatted by the handler later
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return attr
+}
+
+// redactingHandler scrubs the message, which ReplaceAttr doesn't see
+type redactingHandler struct {
+	next slog.Handler
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.Message = Redact(record.Message)
+	return h.next.Handle(ctx, record)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &redactingHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
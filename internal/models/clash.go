@@ -4,22 +4,34 @@ import "time"
 
 // Config represents the mihomo configuration
 type Config struct {
-	Port               int                    `json:"port"`
-	SocksPort          int                    `json:"socks-port"`
-	RedirPort          int                    `json:"redir-port"`
-	TProxyPort         int                    `json:"tproxy-port"`
-	MixedPort          int                    `json:"mixed-port"`
-	Authentication     []string               `json:"authentication"`
-	AllowLan           bool                   `json:"allow-lan"`
-	BindAddress        string                 `json:"bind-address"`
-	Mode               string                 `json:"mode"`
-	Tun                map[string]interface{} `json:"tun"`
-	LogLevel           string                 `json:"log-level"`
-	ExternalController string                 `json:"external-controller"`
-	ExternalUI         string                 `json:"external-ui"`
-	Secret             string                 `json:"secret"`
-	Interface          string                 `json:"interface-name"`
-	RoutingMark        int                    `json:"routing-mark"`
+	Port               int       `json:"port"`
+	SocksPort          int       `json:"socks-port"`
+	RedirPort          int       `json:"redir-port"`
+	TProxyPort         int       `json:"tproxy-port"`
+	MixedPort          int       `json:"mixed-port"`
+	Authentication     []string  `json:"authentication"`
+	AllowLan           bool      `json:"allow-lan"`
+	BindAddress        string    `json:"bind-address"`
+	Mode               string    `json:"mode"`
+	Tun                TunConfig `json:"tun"`
+	LogLevel           string    `json:"log-level"`
+	ExternalController string    `json:"external-controller"`
+	ExternalUI         string    `json:"external-ui"`
+	Secret             string    `json:"secret"`
+	Interface          string    `json:"interface-name"`
+	RoutingMark        int       `json:"routing-mark"`
+}
+
+// TunConfig represents the tun section of the mihomo configuration. Cores
+// without TUN support leave it empty, which reads as disabled.
+type TunConfig struct {
+	Enable              bool     `json:"enable"`
+	Stack               string   `json:"stack"`
+	Device              string   `json:"device"`
+	AutoRoute           bool     `json:"auto-route"`
+	AutoDetectInterface bool     `json:"auto-detect-interface"`
+	DNSHijack           []string `json:"dns-hijack"`
+	MTU                 int      `json:"mtu"`
 }
 
 // Proxy represents a proxy node
@@ -65,6 +77,29 @@ type Connection struct {
 	Chains      []string           `json:"chains"`
 	Rule        string             `json:"rule"`
 	RulePayload string             `json:"rulePayload"`
+
+	// Rates since the previous snapshot, filled in by the connections feed
+	UploadSpeed   int64 `json:"-"`
+	DownloadSpeed int64 `json:"-"`
+}
+
+// ConnectionsSnapshot represents the payload of /connections
+type ConnectionsSnapshot struct {
+	DownloadTotal int64        `json:"downloadTotal"`
+	UploadTotal   int64        `json:"uploadTotal"`
+	Connections   []Connection `json:"connections"`
+	Memory        int64        `json:"memory"`
+}
+
+// ConnectionsDiff represents the change between two connection snapshots
+type ConnectionsDiff struct {
+	Connections   []Connection // Current connections, with rates filled in
+	Added         []Connection // Connections that appeared since the previous snapshot
+	Closed        []Connection // Connections that disappeared since the previous snapshot
+	UploadTotal   int64
+	DownloadTotal int64
+	UploadSpeed   int64
+	DownloadSpeed int64
 }
 
 // ConnectionMetadata represents connection metadata
@@ -102,13 +137,22 @@ type APIResponse struct {
 
 // ProxyProvider represents a proxy provider with its proxies
 type ProxyProvider struct {
-	Name           string   `json:"name"`
-	Type           string   `json:"type"`
-	VehicleType    string   `json:"vehicleType"`
-	Proxies        []*Proxy `json:"proxies"`
-	TestUrl        string   `json:"testUrl"`
-	ExpectedStatus string   `json:"expectedStatus"`
-	UpdatedAt      string   `json:"updatedAt"`
+	Name             string            `json:"name"`
+	Type             string            `json:"type"`
+	VehicleType      string            `json:"vehicleType"`
+	Proxies          []*Proxy          `json:"proxies"`
+	TestUrl          string            `json:"testUrl"`
+	ExpectedStatus   string            `json:"expectedStatus"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+	SubscriptionInfo *SubscriptionInfo `json:"subscriptionInfo,omitempty"`
+}
+
+// SubscriptionInfo represents the usage reported by a subscription provider
+type SubscriptionInfo struct {
+	Upload   int64 `json:"Upload"`
+	Download int64 `json:"Download"`
+	Total    int64 `json:"Total"`
+	Expire   int64 `json:"Expire"` // Unix seconds, 0 if the subscription never expires
 }
 
 // ProvidersResponse represents the response from /providers/proxies API
@@ -116,6 +160,51 @@ type ProvidersResponse struct {
 	Providers map[string]*ProxyProvider `json:"providers"`
 }
 
+// RuleProvider represents a rule provider
+type RuleProvider struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Behavior    string    `json:"behavior"`
+	Format      string    `json:"format"`
+	VehicleType string    `json:"vehicleType"`
+	RuleCount   int       `json:"ruleCount"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// RuleProvidersResponse represents the response from /providers/rules API
+type RuleProvidersResponse struct {
+	Providers map[string]*RuleProvider `json:"providers"`
+}
+
+// DNSQueryResult represents the response from /dns/query API
+type DNSQueryResult struct {
+	Status     int           `json:"Status"` // DNS response code
+	Question   []DNSQuestion `json:"Question"`
+	TC         bool          `json:"TC"`
+	RD         bool          `json:"RD"`
+	RA         bool          `json:"RA"`
+	AD         bool          `json:"AD"`
+	CD         bool          `json:"CD"`
+	Answer     []DNSRecord   `json:"Answer,omitempty"`
+	Authority  []DNSRecord   `json:"Authority,omitempty"`
+	Additional []DNSRecord   `json:"Additional,omitempty"`
+}
+
+// DNSQuestion represents the question section of a DNS message
+type DNSQuestion struct {
+	Name   string `json:"Name"`
+	Qtype  uint16 `json:"Qtype"`
+	Qclass uint16 `json:"Qclass"`
+}
+
+// DNSRecord represents a resource record in a DNS answer
+type DNSRecord struct {
+	Name string `json:"name"`
+	Type uint16 `json:"type"`
+	TTL  uint32 `json:"TTL"`
+	Data string `json:"data"`
+}
+
 type MemoryUsage struct {
 	Inuse   int64 `json:"inuse"`
 	Oslimit int64 `json:"oslimit"`
@@ -123,5 +212,6 @@ type MemoryUsage struct {
 
 type Version struct {
 	Meta    bool   `json:"meta"`
+	Premium bool   `json:"premium"`
 	Version string `json:"version"`
 }
@@ -1,10 +1,12 @@
 package components
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"mihomoTui/internal/api"
-	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
 	"strings"
 
 	"github.com/rivo/tview"
@@ -13,19 +15,22 @@ import (
 // Header represents the top header bar
 type Header struct {
 	*tview.TextView
+	controller  api.Controller
 	appName     string
 	appVersion  string
+	profile     string
 	coreVersion string
-	connected   bool
+	status      api.ConnStatus
 }
 
-// NewHeader creates a new header component
-func NewHeader(appName, version string) *Header {
+// NewHeader creates a new header component showing the core behind
+// controller
+func NewHeader(controller api.Controller, appName, version string) *Header {
 	header := &Header{
 		TextView:   tview.NewTextView(),
+		controller: controller,
 		appName:    appName,
 		appVersion: version,
-		connected:  false,
 	}
 
 	header.setupStyle()
@@ -45,36 +50,78 @@ func (h *Header) setupStyle() {
 
 // updateContent updates the header content
 func (h *Header) updateContent() {
-	status := "[red]○[white]"
-	if h.connected {
+	var status string
+	switch h.status.State {
+	case api.StateConnected:
 		status = "[green]●[white]"
+	case api.StateReconnecting:
+		status = "[yellow]◐[white] reconnecting"
+	case api.StateDown:
+		status = "[red]○[white] down"
+		var apiErr *api.APIError
+		if errors.As(h.status.LastErr, &apiErr) {
+			status += fmt.Sprintf(" (%s)", apiErr.Kind)
+		}
+	default:
+		status = "[gray]◌[white] connecting"
 	}
 
 	content := strings.Builder{}
 	content.WriteString(fmt.Sprintf("%s %s", h.appName, h.appVersion))
+	if h.profile != "" {
+		content.WriteString(fmt.Sprintf(" | Profile: [yellow]%s[white]", tview.Escape(h.profile)))
+	}
 	content.WriteString(fmt.Sprintf(" | coreVer: %s | Status: %s", h.coreVersion, status))
 
 	h.SetText(content.String())
 }
 
-// FetchBasicInfo retrieves basic information about the application
-func (h *Header) SetHeaderInfo() {
-	var connected bool
-	var version *models.Version
-	err := api.Client.HealthCheck()
-	if err != nil {
-		log.Printf("Failed to check health: %v", err)
+// SetProfile shows the active profile. The core version is cleared until
+// the new core reports in. Must be called from the UI goroutine.
+func (h *Header) SetProfile(name string) {
+	if name != h.profile {
+		h.coreVersion = ""
 	}
-	connected = err == nil
-	version, err = api.Client.GetVersion()
+	h.profile = name
+	h.updateContent()
+}
+
+// SetHeaderInfo fetches the core version and follows the connection state
+func (h *Header) SetHeaderInfo() {
+	h.refreshVersion()
+	api.SubscribeStatus(h.onStatusChange)
+}
+
+// onStatusChange redraws the status dot, refetching the version whenever
+// the core comes back, since it may have been upgraded or swapped
+func (h *Header) onStatusChange(status api.ConnStatus) {
+	go ui.Updater.UpdateUi(func() {
+		wasConnected := h.status.State == api.StateConnected
+		h.status = status
+		h.updateContent()
+
+		if status.State == api.StateConnected && !wasConnected {
+			go h.refreshVersion()
+		}
+	})
+}
+
+// refreshVersion identifies the core and probes what it supports, so pages
+// activated later see the capabilities of the core that is running now
+func (h *Header) refreshVersion() {
+	coreVersion := "unknown"
+	caps, err := h.controller.ProbeCapabilities(context.Background())
 	if err != nil {
-		log.Printf("Failed to get version: %v", err)
-	}
-	if version != nil {
-		h.coreVersion = version.Version
+		slog.Warn("Failed to get version", "err", err)
 	} else {
-		h.coreVersion = "unknown"
+		coreVersion = caps.Version
+		if !strings.Contains(coreVersion, caps.Core.String()) {
+			coreVersion += fmt.Sprintf(" (%s)", caps.Core)
+		}
 	}
-	h.connected = connected
-	h.updateContent()
+
+	ui.Updater.UpdateUi(func() {
+		h.coreVersion = coreVersion
+		h.updateContent()
+	})
 }
@@ -3,6 +3,8 @@ package components
 import (
 	"fmt"
 
+	"mihomoTui/internal/ui"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -17,6 +19,7 @@ type Sidebar struct {
 // SidebarItem represents a sidebar menu item
 type SidebarItem struct {
 	Label    string
+	English  string // Label shown when the interface is in English
 	Icon     string
 	Shortcut string
 }
@@ -26,12 +29,17 @@ func NewSidebar() *Sidebar {
 	sidebar := &Sidebar{
 		List: tview.NewList(),
 		items: []SidebarItem{
-			{Label: "仪表板", Icon: "📊", Shortcut: "D"},
-			{Label: "代理", Icon: "🌐", Shortcut: "P"},
-			{Label: "连接", Icon: "🔗", Shortcut: "R"},
-			{Label: "配置", Icon: "⚙️", Shortcut: "C"},
-			{Label: "日志", Icon: "📝", Shortcut: "L"},
-			// {Label: "设置", Icon: "🔧", Shortcut: "S"},
+			{Label: "仪表板", English: "Dashboard", Icon: "📊", Shortcut: "D"},
+			{Label: "代理", English: "Proxies", Icon: "🌐", Shortcut: "P"},
+			{Label: "连接", English: "Connections", Icon: "🔗", Shortcut: "R"},
+			{Label: "配置", English: "Config", Icon: "⚙️", Shortcut: "C"},
+			{Label: "日志", English: "Logs", Icon: "📝", Shortcut: "L"},
+			{Label: "规则", English: "Rules", Icon: "📋", Shortcut: "U"},
+			{Label: "规则集", English: "Rule sets", Icon: "📚", Shortcut: "G"},
+			{Label: "订阅", English: "Providers", Icon: "📡", Shortcut: "B"},
+			{Label: "DNS", English: "DNS", Icon: "🔍", Shortcut: "N"},
+			{Label: "总览", English: "Overview", Icon: "🧭", Shortcut: "O"},
+			{Label: "设置", English: "Settings", Icon: "🔧", Shortcut: "S"},
 		},
 	}
 
@@ -76,6 +84,23 @@ func (s *Sidebar) setupStyle() {
 	})
 }
 
+// SetLanguage shows the item labels in the given ui language
+func (s *Sidebar) SetLanguage(language string) {
+	title := " 导航 "
+	if language == ui.LanguageEnglish {
+		title = " Menu "
+	}
+	s.SetTitle(title)
+
+	for index, item := range s.items {
+		label := item.Label
+		if language == ui.LanguageEnglish {
+			label = item.English
+		}
+		s.SetItemText(index, fmt.Sprintf("%s %s", item.Icon, label), "")
+	}
+}
+
 // SetOnSelect sets the selection callback
 func (s *Sidebar) SetOnSelect(callback func(int, string)) {
 	s.onSelect = callback
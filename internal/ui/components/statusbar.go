@@ -1,13 +1,11 @@
 package components
 
 import (
-	"context"
 	"fmt"
-	"mihomoTui/internal/api"
 	"mihomoTui/internal/models"
 	"mihomoTui/internal/ui"
 	"mihomoTui/internal/utils"
-	"time"
+	"sync"
 
 	"github.com/rivo/tview"
 )
@@ -15,17 +13,21 @@ import (
 // StatusBar represents the bottom status bar
 type StatusBar struct {
 	*tview.TextView
+	store   *ui.Store
 	traffic *models.Traffic
 	config  *models.Config
 
-	ctx    context.Context
-	cancel context.CancelFunc
+	// Unsubscribes from the store while active
+	mutex       sync.Mutex
+	unsubscribe []func()
 }
 
-// NewStatusBar creates a new status bar component
-func NewStatusBar() *StatusBar {
+// NewStatusBar creates a new status bar component showing the state in
+// store
+func NewStatusBar(store *ui.Store) *StatusBar {
 	statusBar := &StatusBar{
 		TextView: tview.NewTextView(),
+		store:    store,
 	}
 
 	statusBar.setupStyle()
@@ -42,49 +44,42 @@ func (s *StatusBar) setupStyle() {
 	s.SetWrap(false)
 }
 
+// Active starts following the config and traffic of the core
 func (s *StatusBar) Active() {
-	s.getConfigData()
-	s.ctx, s.cancel = context.WithCancel(context.Background())
-	go s.startTrafficStream()
-}
-
-func (s *StatusBar) Deactivate() {
-	if s.ctx != nil {
-		s.cancel()
+	s.Deactivate()
+
+	// Subscribing delivers the latest values through the UI thread, so it
+	// must not happen under the mutex Deactivate takes there
+	unsubscribe := []func(){
+		s.store.Config.Subscribe(func(config *models.Config) {
+			ui.Updater.UpdateUi(func() {
+				s.updateConfig(config)
+			})
+		}, nil),
+		s.store.Traffic.Subscribe(func(traffic *models.Traffic) {
+			ui.Updater.UpdateUi(func() {
+				s.updateTraffic(traffic)
+			})
+		}, func(error) {
+			ui.Updater.UpdateUi(func() {
+				s.updateTraffic(nil)
+			})
+		}),
 	}
-}
 
-// getConfigData retrieves and updates the configuration data
-func (s *StatusBar) getConfigData() {
-	if config, err := api.Client.GetConfig(); err == nil {
-		ui.Updater.UpdateUi(func() {
-			s.updateConfig(config)
-		})
-	}
+	s.mutex.Lock()
+	s.unsubscribe = unsubscribe
+	s.mutex.Unlock()
 }
 
-// startTrafficStream starts streaming traffic data
-func (s *StatusBar) startTrafficStream() {
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		default:
-			err := api.StreamClient.StreamTraffic(s.ctx, func(traffic *models.Traffic) {
-				ui.Updater.UpdateUi(func() {
-					s.updateTraffic(traffic)
-				})
-
-			})
-
-			if err != nil && err != context.Canceled {
-				ui.Updater.UpdateUi(func() {
-					s.updateTraffic(nil)
-				})
-				time.Sleep(5 * time.Second)
-			}
-		}
+// Deactivate stops following the core
+func (s *StatusBar) Deactivate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, unsubscribe := range s.unsubscribe {
+		unsubscribe()
 	}
+	s.unsubscribe = nil
 }
 
 // updateTraffic updates traffic information
@@ -99,28 +94,13 @@ func (s *StatusBar) updateConfig(config *models.Config) {
 	s.updateContent()
 }
 
-// GetCurrentMode returns the current proxy mode
-func (s *StatusBar) GetCurrentMode() string {
-	if s.config != nil {
-		return s.config.Mode
-	}
-	return "Unknown"
-}
-
-// UpdateConfig updates the configuration and refreshes the status bar
-func (s *StatusBar) UpdateConfig(config interface{}) {
-	if config, ok := config.(*models.Config); ok {
-		s.updateConfig(config)
-	}
-}
-
 // updateContent updates the status bar content
 func (s *StatusBar) updateContent() {
 	var content string
 
 	// TUN status
 	tunStatus := "○"
-	if s.config != nil && s.config.TProxyPort > 0 {
+	if s.config != nil && s.config.Tun.Enable {
 		tunStatus = "[green]●[white]"
 	}
 
@@ -141,7 +121,7 @@ func (s *StatusBar) updateContent() {
 	}
 
 	// Help text with new shortcuts
-	helpText := "[gray]F1-F6/Ctrl+1-6切换标签页 | ESC返回标签页 | Ctrl+C/Q退出 | Ctrl+R刷新[white]"
+	helpText := "[gray]F1-F11/Ctrl+0-9切换标签页 | ESC返回标签页 | Ctrl+P切换配置 | Ctrl+C/Q退出 | Ctrl+R刷新[white]"
 
 	content = fmt.Sprintf(" TUN: %s | 模式: [yellow]%s[white] | U: [green]%s[white]\t| D: [blue]%s[white]\t| %s",
 		tunStatus, mode, upSpeed, downSpeed, helpText)
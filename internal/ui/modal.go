@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// SetOverlay sets the root pages that modals are layered onto
+func (u *UiUpdater) SetOverlay(pages *tview.Pages) {
+	u.overlay = pages
+}
+
+// ShowModal shows a primitive above the current layout and focuses it.
+// Must be called from the UI goroutine (e.g. a key or button handler).
+func (u *UiUpdater) ShowModal(name string, primitive tview.Primitive) {
+	if u.overlay == nil {
+		return
+	}
+
+	// Modals can open on top of each other, so each one restores the focus
+	// it took over
+	if u.lastFocus == nil {
+		u.lastFocus = make(map[string]tview.Primitive)
+	}
+	if _, shown := u.lastFocus[name]; !shown {
+		u.lastFocus[name] = u.app.GetFocus()
+	}
+	u.overlay.AddPage(name, primitive, true, true)
+	u.app.SetFocus(primitive)
+}
+
+// HideModal removes a modal and restores the focus it replaced.
+// Must be called from the UI goroutine.
+func (u *UiUpdater) HideModal(name string) {
+	if u.overlay == nil || !u.overlay.HasPage(name) {
+		return
+	}
+
+	u.overlay.RemovePage(name)
+	if focus := u.lastFocus[name]; focus != nil {
+		u.app.SetFocus(focus)
+	}
+	delete(u.lastFocus, name)
+}
+
+// HasModal reports whether a modal is currently shown
+func (u *UiUpdater) HasModal() bool {
+	// The first page is always the main layout
+	return u.overlay != nil && u.overlay.GetPageCount() > 1
+}
+
+// Centered wraps a primitive in a layout that centers it at the given size
+func Centered(primitive tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(primitive, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// ShowPicker shows a list of items with current marked and preselected, and calls
+// onSelect with the chosen item. Escape closes it without a choice.
+// Must be called from the UI goroutine.
+func (u *UiUpdater) ShowPicker(name, title string, items []string, current string, onSelect func(item string)) {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetTitle(fmt.Sprintf(" %s ", title))
+
+	width := tview.TaggedStringWidth(title) + 6
+	for i, item := range items {
+		marker := "  "
+		if item == current {
+			marker = "● "
+		}
+		list.AddItem(marker+tview.Escape(item), "", 0, func() {
+			u.HideModal(name)
+			onSelect(item)
+		})
+		if item == current {
+			list.SetCurrentItem(i)
+		}
+		width = max(width, tview.TaggedStringWidth(item)+8)
+	}
+	list.SetDoneFunc(func() {
+		u.HideModal(name)
+	})
+
+	u.ShowModal(name, Centered(list, width, min(len(items), 15)+2))
+}
@@ -1,16 +1,30 @@
 package pages
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 
 	"mihomoTui/internal/api"
 	"mihomoTui/internal/config"
+	"mihomoTui/internal/discovery"
 	"mihomoTui/internal/ui"
 
 	"github.com/rivo/tview"
 )
 
+// insecureLabel labels the checkbox that disables certificate verification
+const insecureLabel = "跳过证书验证"
+
+// profileModal is the overlay name used by the profile dialogs
+const profileModal = "profile"
+
+// discoveryModal is the overlay name of the discovered controller picker
+const discoveryModal = "discovery"
+
 // Config represents the config page
 type Config struct {
 	*ConfigPage
@@ -18,13 +32,13 @@ type Config struct {
 
 // Activate activates the config page
 func (c *Config) Activate() {
-	log.Printf("Activating config page")
+	slog.Debug("Activating page", "page", "config")
 	c.ConfigPage.Activate()
 }
 
 // Deactivate deactivates the config page
 func (c *Config) Deactivate() {
-	log.Printf("Deactivating config page")
+	slog.Debug("Deactivating page", "page", "config")
 	c.ConfigPage.Deactivate()
 }
 
@@ -40,19 +54,20 @@ type ConfigPage struct {
 	// Labels
 	labels []string
 
-	// Current config values
-	currentConfig *config.AppConfig
+	// Called after the active profile changed or was edited, so the app
+	// can reconnect to its core
+	onProfileChange func()
 }
 
 // NewConfigPage creates a new configuration page
-func NewConfigPage(configManager *config.Manager) *ConfigPage {
+func NewConfigPage(configManager *config.Manager, onProfileChange func()) *ConfigPage {
 	page := &ConfigPage{
-		Flex:          tview.NewFlex(),
-		configManager: configManager,
-		form:          tview.NewForm(),
-		statusText:    tview.NewTextView(),
-		currentConfig: configManager.Get(),
-		labels:        []string{"API地址", "API密钥"},
+		Flex:            tview.NewFlex(),
+		configManager:   configManager,
+		form:            tview.NewForm(),
+		statusText:      tview.NewTextView(),
+		onProfileChange: onProfileChange,
+		labels:          []string{"配置名称", "API地址", "API密钥", "CA证书", "客户端证书", "客户端私钥", "证书指纹", "SSH主机", "SSH用户", "SSH密钥", "known_hosts"},
 	}
 
 	page.setupUI()
@@ -84,11 +99,32 @@ func (c *ConfigPage) setUpFormItems() {
 	for _, label := range c.labels {
 		c.form.AddInputField(label, "", 50, nil, nil)
 	}
+	c.form.AddCheckbox(insecureLabel, false, nil)
+
+	placeholders := map[string]string{
+		"配置名称":        "如 home、office",
+		"API地址":       "http://127.0.0.1:9090 或 unix:///path/to/mihomo.sock",
+		"CA证书":        "可选，PEM 格式 CA 文件路径",
+		"客户端证书":       "可选，双向 TLS 客户端证书",
+		"客户端私钥":       "可选，客户端证书对应的私钥",
+		"证书指纹":        "可选，SHA-256 指纹，如 AB:CD:...",
+		"SSH主机":       "可选，经 SSH 访问远程核心，如 router.lan:22",
+		"SSH用户":       "默认为当前用户",
+		"SSH密钥":       "默认使用 ssh-agent",
+		"known_hosts": "默认为 ~/.ssh/known_hosts",
+	}
+	for label, placeholder := range placeholders {
+		c.form.GetFormItemByLabel(label).(*tview.InputField).SetPlaceholder(placeholder)
+	}
 
 	// Action buttons
 	c.form.AddButton("保存", c.saveConfig)
 	c.form.AddButton("重置", c.resetConfig)
 	c.form.AddButton("测试连接", c.testConnection)
+	c.form.AddButton("自动发现", c.discoverControllers)
+	c.form.AddButton("切换配置", c.switchProfile)
+	c.form.AddButton("新建配置", c.addProfile)
+	c.form.AddButton("删除配置", c.removeProfile)
 }
 
 // Activate activates the config page
@@ -103,11 +139,14 @@ func (c *ConfigPage) Activate() {
 func (c *ConfigPage) Deactivate() {
 }
 
-// updateConfigForm loads current configuration into form fields
+// updateConfigForm loads current configuration into form fields. The
+// config is read from the manager each time, since other pages replace it.
 func (c *ConfigPage) updateConfigForm() {
+	cfg := c.configManager.Get()
 	for _, label := range c.labels {
-		c.form.GetFormItemByLabel(label).(*tview.InputField).SetText(c.currentConfig.GetValue(label))
+		c.form.GetFormItemByLabel(label).(*tview.InputField).SetText(cfg.GetValue(label))
 	}
+	c.form.GetFormItemByLabel(insecureLabel).(*tview.Checkbox).SetChecked(cfg.Active().API.TLS.InsecureSkipVerify)
 	c.statusText.SetText("[green]配置加载完毕[white]")
 }
 
@@ -119,62 +158,247 @@ func (c *ConfigPage) saveConfig() {
 		return
 	}
 
-	// Get current config and only update API settings
-	newConfig := *c.currentConfig // Copy current config
-
-	// Update API settings from form
-	for _, value := range c.labels {
-		newConfig.SetValue(value, c.form.GetFormItemByLabel(value).(*tview.InputField).GetText())
-	}
-
-	// Validate
-	tempManager := &config.Manager{}
-	tempManager.Set(&newConfig)
-	if err := tempManager.Validate(); err != nil {
+	newConfig, err := c.formConfig()
+	if err != nil {
 		c.showStatus(fmt.Sprintf("[red]配置无效: %v[white]", err))
 		return
 	}
 
 	// Save
-	c.configManager.Set(&newConfig)
+	oldConfig := c.configManager.Get()
+	oldProfile := *oldConfig.Active()
+	c.configManager.Set(newConfig)
 	if err := c.configManager.Save(); err != nil {
+		c.configManager.Set(oldConfig)
 		c.showStatus(fmt.Sprintf("[red]保存失败: %v[white]", err))
 		return
 	}
 
-	// Update API
-	api.UpdateClient(newConfig.API.BaseURL, newConfig.API.Secret)
+	// Reconnect only when the active core's settings changed
+	if *newConfig.Active() != oldProfile {
+		c.onProfileChange()
+	}
 
-	c.currentConfig = &newConfig
 	c.showStatus("[green]配置已保存[white]")
 }
 
-// resetConfig resets configuration to defaults
+// resetConfig resets the controller settings of the active profile to
+// defaults, keeping its name
 func (c *ConfigPage) resetConfig() {
-	if err := c.configManager.Reset(); err != nil {
+	oldConfig := c.configManager.Get()
+	newConfig := oldConfig.Clone()
+	newConfig.Active().API = config.DefaultAPIConfig()
+
+	c.configManager.Set(newConfig)
+	if err := c.configManager.Save(); err != nil {
+		c.configManager.Set(oldConfig)
 		c.showStatus(fmt.Sprintf("[red]重置失败: %v[white]", err))
 		return
 	}
-	c.currentConfig = c.configManager.Get()
 
-	api.UpdateClient(c.currentConfig.API.BaseURL, c.currentConfig.API.Secret)
+	c.onProfileChange()
 	go ui.Updater.UpdateUi(
 		func() {
-			// Update API
 			c.updateConfigForm()
 			c.showStatus("[yellow]配置已重置为默认值[white]")
 		})
 }
 
-// testConnection tests the API connection
+// switchProfile lets the user pick another profile to connect to
+func (c *ConfigPage) switchProfile() {
+	cfg := c.configManager.Get()
+	active := cfg.Active().Name
+	ui.Updater.ShowPicker(profileModal, "切换配置", cfg.ProfileNames(), active, func(name string) {
+		if name == active {
+			return
+		}
+		if err := c.configManager.SetActiveProfile(name); err != nil {
+			c.showStatus(fmt.Sprintf("[red]切换失败: %v[white]", err))
+			return
+		}
+		c.profileSwitched(fmt.Sprintf("[green]已切换到配置 %s[white]", tview.Escape(name)))
+	})
+}
+
+// addProfile asks for a name and creates a profile for a local core
+func (c *ConfigPage) addProfile() {
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle(" 新建配置 ")
+	form.AddInputField("配置名称", "", 30, nil, nil)
+	form.AddButton("创建", func() {
+		name := strings.TrimSpace(form.GetFormItemByLabel("配置名称").(*tview.InputField).GetText())
+		ui.Updater.HideModal(profileModal)
+
+		if err := c.configManager.AddProfile(name); err != nil {
+			c.showStatus(fmt.Sprintf("[red]创建失败: %v[white]", err))
+			return
+		}
+		c.profileSwitched(fmt.Sprintf("[green]已创建配置 %s，请填写 API 地址后保存[white]", tview.Escape(name)))
+	})
+	form.AddButton("取消", func() {
+		ui.Updater.HideModal(profileModal)
+	})
+	form.SetCancelFunc(func() {
+		ui.Updater.HideModal(profileModal)
+	})
+
+	ui.Updater.ShowModal(profileModal, ui.Centered(form, 50, 7))
+}
+
+// removeProfile deletes the active profile after confirmation
+func (c *ConfigPage) removeProfile() {
+	cfg := c.configManager.Get()
+	name := cfg.Active().Name
+	if len(cfg.Profiles) == 1 {
+		c.showStatus("[yellow]至少需要保留一个配置[white]")
+		return
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("确定要删除配置 %s 吗？", name)).
+		AddButtons([]string{"确定", "取消"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			ui.Updater.HideModal(profileModal)
+			if buttonLabel != "确定" {
+				return
+			}
+			if err := c.configManager.RemoveProfile(name); err != nil {
+				c.showStatus(fmt.Sprintf("[red]删除失败: %v[white]", err))
+				return
+			}
+			c.profileSwitched(fmt.Sprintf("[yellow]已删除配置 %s[white]", tview.Escape(name)))
+		})
+
+	ui.Updater.ShowModal(profileModal, modal)
+}
+
+// profileSwitched reconnects after another profile became active and
+// loads it into the form. Must be called from the UI goroutine.
+func (c *ConfigPage) profileSwitched(message string) {
+	c.onProfileChange()
+	c.updateConfigForm()
+	c.showStatus(message)
+}
+
+// testConnection tests the address and secret currently in the form,
+// without saving them
 func (c *ConfigPage) testConnection() {
-	c.showStatus("[yellow]正在测试连接...[white]")
+	testConfig, err := c.formConfig()
+	if err != nil {
+		c.showStatus(fmt.Sprintf("[red]配置无效: %v[white]", err))
+		return
+	}
+
+	testAPI := testConfig.Active().API
+	c.showStatus(fmt.Sprintf("[yellow]正在测试连接 %s...[white]", testAPI.BaseURL))
+
+	go func() {
+		client := api.NewHttpClient(testAPI, 5*time.Second)
+		defer client.Close()
+		ctx := context.Background()
+
+		var message string
+		if err := client.HealthCheck(ctx); err != nil {
+			message = fmt.Sprintf("[red]连接失败: %s[white]", describeError(err))
+		} else if version, err := client.GetVersion(ctx); err != nil {
+			message = fmt.Sprintf("[red]连接成功但验证失败: %s[white]", describeError(err))
+		} else {
+			message = fmt.Sprintf("[green]连接成功! 核心版本 %s[white]", version.Version)
+		}
+
+		ui.Updater.UpdateUi(func() {
+			c.showStatus(message)
+		})
+	}()
+}
+
+// discoverControllers looks for local cores and lets the user pick one to
+// fill into the form. Nothing is saved until the user saves the form.
+func (c *ConfigPage) discoverControllers() {
+	c.showStatus("[yellow]正在查找本机核心...[white]")
+
+	go func() {
+		candidates := discovery.Discover(context.Background())
+
+		ui.Updater.UpdateUi(func() {
+			if len(candidates) == 0 {
+				c.showStatus("[yellow]未找到核心，请确认核心已启动或手动填写 API 地址[white]")
+				return
+			}
+
+			labels := make([]string, len(candidates))
+			byLabel := make(map[string]discovery.Candidate, len(candidates))
+			for i, candidate := range candidates {
+				labels[i] = candidateLabel(candidate)
+				byLabel[labels[i]] = candidate
+			}
+
+			c.showStatus(fmt.Sprintf("[green]找到 %d 个核心[white]", len(candidates)))
+			ui.Updater.ShowPicker(discoveryModal, "选择核心", labels, "", func(label string) {
+				c.fillCandidate(byLabel[label])
+			})
+		})
+	}()
+}
+
+// fillCandidate puts a discovered controller into the form
+func (c *ConfigPage) fillCandidate(candidate discovery.Candidate) {
+	c.form.GetFormItemByLabel("API地址").(*tview.InputField).SetText(candidate.API.BaseURL)
+	c.form.GetFormItemByLabel("API密钥").(*tview.InputField).SetText(candidate.API.Secret)
+
+	message := fmt.Sprintf("[green]已填入 %s，保存后生效[white]", tview.Escape(candidate.API.BaseURL))
+	switch {
+	case api.IsKind(candidate.Err, api.KindUnauthorized):
+		message = fmt.Sprintf("[yellow]已填入 %s，请填写正确的 API 密钥后保存[white]", tview.Escape(candidate.API.BaseURL))
+	case !candidate.Reachable():
+		message = fmt.Sprintf("[yellow]已填入 %s，但核心未响应: %s[white]", tview.Escape(candidate.API.BaseURL), describeError(candidate.Err))
+	}
+	c.showStatus(message)
+}
+
+// candidateLabel describes a discovered controller in the picker
+func candidateLabel(candidate discovery.Candidate) string {
+	status := candidate.Version
+	switch {
+	case api.IsKind(candidate.Err, api.KindUnauthorized):
+		status = "需要密钥"
+	case !candidate.Reachable():
+		status = "未运行"
+	}
+
+	source := candidate.Source
+	if source == discovery.PortScan {
+		source = "端口扫描"
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		if rest, ok := strings.CutPrefix(source, homeDir); ok {
+			source = "~" + rest
+		}
+	}
 
-	if err := api.Client.HealthCheck(); err != nil {
-		c.showStatus(fmt.Sprintf("[red]连接失败: %v[white]", err))
-	} else {
-		c.showStatus("[green]连接成功![white]")
+	return fmt.Sprintf("%s  %s  (%s)", candidate.API.BaseURL, status, source)
+}
+
+// formConfig returns a copy of the current config with the form applied
+// to the active profile, after validating it
+func (c *ConfigPage) formConfig() (*config.AppConfig, error) {
+	newConfig := c.configManager.Get().Clone()
+
+	for _, label := range c.labels {
+		newConfig.SetValue(label, c.form.GetFormItemByLabel(label).(*tview.InputField).GetText())
 	}
+	newConfig.Active().API.TLS.InsecureSkipVerify = c.form.GetFormItemByLabel(insecureLabel).(*tview.Checkbox).IsChecked()
+
+	tempManager := &config.Manager{}
+	tempManager.Set(newConfig)
+	if err := tempManager.Validate(); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateTLS(newConfig.Active().API.TLS); err != nil {
+		return nil, err
+	}
+
+	return newConfig, nil
 }
 
 // showStatus displays a status message
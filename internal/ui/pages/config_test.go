@@ -0,0 +1,43 @@
+package pages
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/logging"
+	"mihomoTui/internal/ui"
+)
+
+func TestConfigPageKeepsSettings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	manager := config.NewManager()
+	if err := manager.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	t.Cleanup(func() { logging.SetLevel("info") })
+
+	// Both pages exist before the settings change, as in the app
+	configPage := NewConfigPage(manager, func() {})
+	settingsPage := NewSettingsPage(manager, func() {}, func() {})
+
+	settingsPage.form.GetFormItemByLabel("界面语言").(*tview.DropDown).SetCurrentOption(ui.ChoiceIndex(ui.Languages, ui.LanguageEnglish))
+	settingsPage.form.GetFormItemByLabel("主题").(*tview.DropDown).SetCurrentOption(ui.ChoiceIndex(ui.Themes, ui.ThemeTerminal))
+	settingsPage.form.GetFormItemByLabel("日志级别").(*tview.DropDown).SetCurrentOption(logLevelIndex("debug"))
+	settingsPage.save()
+
+	configPage.updateConfigForm()
+	configPage.saveConfig()
+
+	// Reload from disk so the saved file is checked, not just memory
+	reloaded := config.NewManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for name, cfg := range map[string]*config.AppConfig{"in memory": manager.Get(), "on disk": reloaded.Get()} {
+		if cfg.UI.Language != ui.LanguageEnglish || cfg.UI.Theme != ui.ThemeTerminal || cfg.Log.Level != "debug" {
+			t.Errorf("%s after saving the config page: UI %+v, log level %q", name, cfg.UI, cfg.Log.Level)
+		}
+	}
+}
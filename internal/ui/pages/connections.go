@@ -3,7 +3,7 @@ package pages
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"mihomoTui/internal/api"
 	"mihomoTui/internal/models"
 	"mihomoTui/internal/ui"
@@ -22,19 +22,21 @@ type Connections struct {
 
 // Activate activates the connections page
 func (c *Connections) Activate() {
-	log.Printf("Activating connections page")
+	slog.Debug("Activating page", "page", "connections")
 	c.ConnectionsPage.Activate()
 }
 
 // Deactivate deactivates the connections page
 func (c *Connections) Deactivate() {
-	log.Printf("Deactivating connections page")
+	slog.Debug("Deactivating page", "page", "connections")
 	c.ConnectionsPage.Deactivate()
 }
 
 // ConnectionsPage represents the connections management page
 type ConnectionsPage struct {
 	*tview.Flex
+	controller api.Controller
+	store      *ui.Store
 
 	// Components
 	connectionsTable *tview.Table
@@ -44,11 +46,14 @@ type ConnectionsPage struct {
 	// Data
 	connections    []models.Connection
 	selectedConnID string
+	uploadSpeed    int64
+	downloadSpeed  int64
 
 	// Control
-	mutex         sync.RWMutex
-	refreshTicker *time.Ticker
-	refreshCancel context.CancelFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mutex       sync.RWMutex
+	unsubscribe func() // Stops the connections feed
 
 	// State
 	isActive     bool
@@ -58,9 +63,11 @@ type ConnectionsPage struct {
 }
 
 // NewConnectionsPage creates a new connections management page
-func NewConnectionsPage() *ConnectionsPage {
+func NewConnectionsPage(controller api.Controller, store *ui.Store) *ConnectionsPage {
 	page := &ConnectionsPage{
 		Flex:        tview.NewFlex(),
+		controller:  controller,
+		store:       store,
 		connections: make([]models.Connection, 0),
 		autoRefresh: true,
 	}
@@ -75,13 +82,19 @@ func NewConnectionsPage() *ConnectionsPage {
 func (c *ConnectionsPage) Activate() {
 	c.mutex.Lock()
 	c.isActive = true
+	c.ctx, c.cancel = context.WithCancel(context.Background())
 	c.mutex.Unlock()
 
-	// Load initial data
-	c.loadConnectionsData()
+	c.mutex.RLock()
+	autoRefresh := c.autoRefresh
+	c.mutex.RUnlock()
 
-	// Start auto-refresh
-	c.startAutoRefresh()
+	// Live updates come from the connections feed; load once when paused
+	if autoRefresh {
+		c.startConnectionsFeed()
+	} else {
+		c.loadConnectionsData()
+	}
 
 	go ui.Updater.UpdateUi(func() {
 		c.statusText.SetText("连接页面已激活")
@@ -98,8 +111,11 @@ func (c *ConnectionsPage) Deactivate() {
 	c.selectedConnID = ""
 	c.mutex.Unlock()
 
-	// Stop auto-refresh
-	c.stopAutoRefresh()
+	// Stop the connections feed and any request still in flight
+	c.stopConnectionsFeed()
+	if c.cancel != nil {
+		c.cancel()
+	}
 }
 
 // setupLayout sets up the connections page layout
@@ -131,7 +147,7 @@ func (c *ConnectionsPage) createConnectionsTable() {
 	c.connectionsTable.SetSelectable(true, false)
 
 	// Set table headers
-	headers := []string{"ID", "网络", "源地址", "目标地址", "代理链", "规则", "上传", "下载", "持续时间"}
+	headers := []string{"ID", "网络", "源地址", "目标地址", "代理链", "规则", "上传", "下载", "↑速度", "↓速度", "持续时间"}
 	for i, header := range headers {
 		cell := tview.NewTableCell(header).
 			SetTextColor(tcell.ColorYellow).
@@ -216,9 +232,9 @@ func (c *ConnectionsPage) loadConnectionsData() {
 		return // Page is not active
 	}
 
-	connections, err := api.Client.GetConnections()
+	connections, err := c.controller.GetConnections(c.ctx)
 	if err != nil {
-		c.showError(fmt.Sprintf("获取连接数据失败: %v", err))
+		c.showError(fmt.Sprintf("获取连接数据失败: %s", describeError(err)))
 		return
 	}
 
@@ -226,25 +242,47 @@ func (c *ConnectionsPage) loadConnectionsData() {
 	// Double check if we're still active after API call
 	if c.isActive {
 		c.connections = connections
+		c.uploadSpeed = 0
+		c.downloadSpeed = 0
 		c.lastUpdate = time.Now()
 		c.refreshCount++
 	}
 	c.mutex.Unlock()
 }
 
+// onConnectionsUpdate applies a diffed snapshot from the connections feed
+func (c *ConnectionsPage) onConnectionsUpdate(diff *models.ConnectionsDiff) {
+	c.mutex.Lock()
+	if !c.isActive {
+		c.mutex.Unlock()
+		return
+	}
+	c.connections = diff.Connections
+	c.uploadSpeed = diff.UploadSpeed
+	c.downloadSpeed = diff.DownloadSpeed
+	c.lastUpdate = time.Now()
+	c.refreshCount++
+	c.mutex.Unlock()
+
+	ui.Updater.UpdateUi(func() {
+		c.updateConnectionsTable()
+	})
+}
+
 // updateConnectionsTable updates the connections table
 func (c *ConnectionsPage) updateConnectionsTable() {
 	c.mutex.RLock()
 	connections := c.connections
+	selectedConnID := c.selectedConnID
 	c.mutex.RUnlock()
 
-	// Clear existing rows (except header)
-	rowCount := c.connectionsTable.GetRowCount()
-	for i := rowCount - 1; i > 0; i-- {
+	// Drop rows of closed connections; remaining rows are overwritten in place
+	for i := c.connectionsTable.GetRowCount() - 1; i > len(connections); i-- {
 		c.connectionsTable.RemoveRow(i)
 	}
 
-	// Add connection rows
+	// Update connection rows
+	selectedRow := 0
 	for i, conn := range connections {
 		row := i + 1
 
@@ -278,6 +316,8 @@ func (c *ConnectionsPage) updateConnectionsTable() {
 		// Format upload/download
 		upload := c.formatBytes(conn.Upload)
 		download := c.formatBytes(conn.Download)
+		uploadSpeed := c.formatSpeed(conn.UploadSpeed)
+		downloadSpeed := c.formatSpeed(conn.DownloadSpeed)
 
 		// Calculate duration
 		duration := time.Since(conn.Start).Truncate(time.Second).String()
@@ -301,6 +341,8 @@ func (c *ConnectionsPage) updateConnectionsTable() {
 			{rule, tcell.ColorPurple, tview.AlignLeft},
 			{upload, tcell.ColorRed, tview.AlignRight},
 			{download, tcell.ColorGreen, tview.AlignRight},
+			{uploadSpeed, tcell.ColorRed, tview.AlignRight},
+			{downloadSpeed, tcell.ColorGreen, tview.AlignRight},
 			{duration, tcell.ColorGray, tview.AlignRight},
 		}
 
@@ -311,15 +353,24 @@ func (c *ConnectionsPage) updateConnectionsTable() {
 			cell.SetReference(conn.ID) // Store full ID in reference
 			c.connectionsTable.SetCell(row, j, cell)
 		}
+
+		if conn.ID == selectedConnID {
+			selectedRow = row
+		}
 	}
 
 	// Update status
 	c.updateStatus()
 
-	// Select first connection if none selected and connections exist
-	if len(connections) > 0 {
+	// Keep the selection on the same connection as rows come and go
+	if selectedRow > 0 {
+		if row, _ := c.connectionsTable.GetSelection(); row != selectedRow {
+			c.connectionsTable.Select(selectedRow, 0)
+		}
+		c.updateInfoPanel(connections[selectedRow-1])
+	} else if len(connections) > 0 {
 		row, _ := c.connectionsTable.GetSelection()
-		if row == 0 {
+		if row == 0 || row > len(connections) {
 			c.connectionsTable.Select(1, 0)
 		}
 	}
@@ -335,6 +386,7 @@ func (c *ConnectionsPage) updateInfoPanel(conn models.Connection) {
 [yellow]代理链:[white] %s
 [yellow]规则:[white] %s (%s)
 [yellow]上传/下载:[white] %s / %s (总: %s)
+[yellow]速度:[white] ↑%s ↓%s
 [yellow]开始:[white] %s
 [yellow]持续:[white] %s
 [yellow]DNS:[white] %s
@@ -347,6 +399,7 @@ func (c *ConnectionsPage) updateInfoPanel(conn models.Connection) {
 		c.formatChains(conn.Chains),
 		c.safeString(conn.Rule, "DIRECT"), c.safeString(conn.RulePayload, "无"),
 		c.formatBytes(conn.Upload), c.formatBytes(conn.Download), c.formatBytes(conn.Upload+conn.Download),
+		c.formatSpeed(conn.UploadSpeed), c.formatSpeed(conn.DownloadSpeed),
 		conn.Start.Format("2006-01-02 15:04:05"),
 		time.Since(conn.Start).Truncate(time.Second).String(),
 		c.safeString(conn.Metadata.DNSMode, "未知"),
@@ -373,6 +426,14 @@ func (c *ConnectionsPage) formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f%cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// formatSpeed formats a byte rate for display
+func (c *ConnectionsPage) formatSpeed(bytesPerSecond int64) string {
+	if bytesPerSecond <= 0 {
+		return "-"
+	}
+	return c.formatBytes(bytesPerSecond) + "/s"
+}
+
 // formatChains formats proxy chains for display
 func (c *ConnectionsPage) formatChains(chains []string) string {
 	if len(chains) == 0 {
@@ -396,6 +457,8 @@ func (c *ConnectionsPage) updateStatus() {
 	lastUpdate := c.lastUpdate
 	refreshCount := c.refreshCount
 	autoRefresh := c.autoRefresh
+	uploadSpeed := c.uploadSpeed
+	downloadSpeed := c.downloadSpeed
 	c.mutex.RUnlock()
 
 	autoRefreshIcon := "⏸"
@@ -404,7 +467,7 @@ func (c *ConnectionsPage) updateStatus() {
 	}
 
 	status := fmt.Sprintf(`[green]● %d[white] 连接 | [yellow]%s[white] 更新:%d | [cyan]%s[white] 自动刷新
-
+[red]↑ %s[white] [green]↓ %s[white]
 [gray]快捷键:[white]
 [yellow]F5/R[white] 刷新 [yellow]T[white] 自动 [yellow]D/Del[white] 关闭连接
 [yellow]↑↓[white] 选择连接`,
@@ -412,6 +475,8 @@ func (c *ConnectionsPage) updateStatus() {
 		lastUpdate.Format("15:04"),
 		refreshCount,
 		autoRefreshIcon,
+		c.formatSpeed(uploadSpeed),
+		c.formatSpeed(downloadSpeed),
 	)
 
 	go ui.Updater.UpdateUi(func() {
@@ -438,23 +503,24 @@ func (c *ConnectionsPage) closeSelectedConnection() {
 			return
 		}
 
-		err := api.Client.CloseConnection(c.selectedConnID)
+		err := c.controller.CloseConnection(c.ctx, c.selectedConnID)
 		if err != nil {
-			c.showError(fmt.Sprintf("关闭连接失败: %v", err))
+			c.showError(fmt.Sprintf("关闭连接失败: %s", describeError(err)))
 			return
 		}
 
 		c.showSuccess("连接已关闭")
 
-		// Refresh data to remove closed connection
+		// The feed drops closed connections on its next tick; reload only when paused
 		time.Sleep(500 * time.Millisecond)
 
 		// Check again if we're still active
 		c.mutex.RLock()
 		isActive = c.isActive
+		autoRefresh := c.autoRefresh
 		c.mutex.RUnlock()
 
-		if isActive {
+		if isActive && !autoRefresh {
 			c.loadConnectionsData()
 			go ui.Updater.UpdateUi(func() {
 				c.updateConnectionsTable()
@@ -490,85 +556,66 @@ func (c *ConnectionsPage) toggleAutoRefresh() {
 	c.mutex.Unlock()
 
 	if autoRefresh {
-		c.startAutoRefresh()
+		// The latest connections are delivered right away and need the UI
+		// thread, which is running this handler
+		go c.startConnectionsFeed()
 		c.showSuccess("自动刷新已开启")
 	} else {
-		c.stopAutoRefresh()
+		c.stopConnectionsFeed()
 		c.showSuccess("自动刷新已关闭")
 	}
 
 	c.updateStatus()
 }
 
-// startAutoRefresh starts the auto-refresh goroutine
-func (c *ConnectionsPage) startAutoRefresh() {
-	c.stopAutoRefresh() // Stop existing refresh if any
+// startConnectionsFeed subscribes to the shared connections feed
+func (c *ConnectionsPage) startConnectionsFeed() {
+	c.stopConnectionsFeed() // Stop existing feed if any
 
-	c.mutex.Lock()
+	c.mutex.RLock()
 	isActive := c.isActive
-	c.mutex.Unlock()
+	c.mutex.RUnlock()
 
 	if !isActive {
 		return // Page is not active
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	c.refreshCancel = cancel
-	c.refreshTicker = time.NewTicker(2 * time.Second) // Refresh every 2 seconds
+	unsubscribe := c.store.Connections.Subscribe(c.onConnectionsUpdate, func(err error) {
+		c.showError(fmt.Sprintf("连接数据流中断: %s", describeError(err)))
+	})
 
-	go func() {
-		defer c.refreshTicker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-c.refreshTicker.C:
-				c.mutex.RLock()
-				isActive := c.isActive
-				autoRefresh := c.autoRefresh
-				c.mutex.RUnlock()
-
-				if !isActive || !autoRefresh {
-					return
-				}
-
-				c.loadConnectionsData()
-				go ui.Updater.UpdateUi(func() {
-					c.updateConnectionsTable()
-				})
-			}
-		}
-	}()
+	c.mutex.Lock()
+	c.unsubscribe = unsubscribe
+	c.mutex.Unlock()
 }
 
-// stopAutoRefresh stops the auto-refresh goroutine
-func (c *ConnectionsPage) stopAutoRefresh() {
-	if c.refreshCancel != nil {
-		c.refreshCancel()
-		c.refreshCancel = nil
-	}
-	if c.refreshTicker != nil {
-		c.refreshTicker.Stop()
-		c.refreshTicker = nil
+// stopConnectionsFeed stops the connections feed
+func (c *ConnectionsPage) stopConnectionsFeed() {
+	c.mutex.Lock()
+	unsubscribe := c.unsubscribe
+	c.unsubscribe = nil
+	c.mutex.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
 	}
 }
 
 // showError shows an error message
 func (c *ConnectionsPage) showError(message string) {
-	log.Printf("Error: %s", message)
+	slog.Warn(message, "page", "connections")
 	// We'll update status instead of having a separate error display
 }
 
 // showSuccess shows a success message
 func (c *ConnectionsPage) showSuccess(message string) {
-	log.Printf("Success: %s", message)
+	slog.Info(message, "page", "connections")
 	// We'll update status instead of having a separate success display
 }
 
 // showInfo shows an info message
 func (c *ConnectionsPage) showInfo(message string) {
-	log.Printf("Info: %s", message)
+	slog.Debug(message, "page", "connections")
 	// We'll update status instead of having a separate info display
 }
 
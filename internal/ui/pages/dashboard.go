@@ -3,7 +3,7 @@ package pages
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"mihomoTui/internal/api"
 	"mihomoTui/internal/models"
 	"mihomoTui/internal/ui"
@@ -23,19 +23,21 @@ type Dashboard struct {
 
 // Activate activates the dashboard page
 func (d *Dashboard) Activate() {
-	log.Printf("Activating dashboard page")
+	slog.Debug("Activating page", "page", "dashboard")
 	d.DashboardPage.Activate()
 }
 
 // Deactivate deactivates the dashboard page
 func (d *Dashboard) Deactivate() {
-	log.Printf("Deactivating dashboard page")
+	slog.Debug("Deactivating page", "page", "dashboard")
 	d.DashboardPage.Deactivate()
 }
 
 // DashboardPage represents the main dashboard page
 type DashboardPage struct {
 	*tview.Flex
+	controller api.Controller
+	store      *ui.Store
 
 	// Components
 	connectionsBox  *tview.TextView
@@ -43,6 +45,7 @@ type DashboardPage struct {
 	controlButtons  *tview.Flex
 	allowLanBtn     *tview.Button
 	tunBtn          *tview.Button
+	maintenanceBtn  *tview.Button
 	statusText      *tview.TextView
 	operationStatus *tview.TextView // Status bar for operation results
 
@@ -52,23 +55,28 @@ type DashboardPage struct {
 
 	// Data
 	connectionsData []models.Connection
+	connectionsDiff *models.ConnectionsDiff
 	memoryData      *models.MemoryUsage
 	configData      *models.Config
 
 	// Control
-	ctx    context.Context
-	cancel context.CancelFunc
-	mutex  sync.RWMutex
-
-	// Update frequency
-	updateInterval time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mutex       sync.RWMutex
+	unsubscribe []func() // Store subscriptions while active
+
+	// State
+	caps               api.Capabilities // Features of the core, probed on activation
+	maintenanceRunning bool
+	statusSeq          int // Bumped on every operation status change
 }
 
 // NewDashboardPage creates a new dashboard page
-func NewDashboardPage() *DashboardPage {
+func NewDashboardPage(controller api.Controller, store *ui.Store) *DashboardPage {
 	dashboard := &DashboardPage{
-		Flex:           tview.NewFlex(),
-		updateInterval: 2 * time.Second,
+		Flex:       tview.NewFlex(),
+		controller: controller,
+		store:      store,
 	}
 
 	dashboard.setupLayout()
@@ -141,14 +149,21 @@ func (d *DashboardPage) createControlButtons() {
 	d.tunBtn.SetStyle(tcell.StyleDefault.Background(tcell.ColorDeepSkyBlue))
 	d.tunBtn.SetSelectedFunc(d.toggleTun)
 
+	// Create maintenance button
+	d.maintenanceBtn = tview.NewButton("维护")
+	d.maintenanceBtn.SetStyle(tcell.StyleDefault.Background(tcell.ColorDarkSlateGray))
+	d.maintenanceBtn.SetSelectedFunc(d.showMaintenancePanel)
+
 	// Initialize focusable buttons array
-	d.focusableButtons = []*tview.Button{d.allowLanBtn, d.tunBtn}
+	d.focusableButtons = []*tview.Button{d.allowLanBtn, d.tunBtn, d.maintenanceBtn}
 	d.currentButtonIndex = 0
 
 	// Add buttons to buttons row
 	buttonsRow.AddItem(d.allowLanBtn, 0, 2, true)
 	buttonsRow.AddItem(nil, 0, 1, false)
 	buttonsRow.AddItem(d.tunBtn, 0, 2, false)
+	buttonsRow.AddItem(nil, 0, 1, false)
+	buttonsRow.AddItem(d.maintenanceBtn, 0, 2, false)
 
 	// Create status text view for mode and port info
 	d.statusText = tview.NewTextView()
@@ -213,78 +228,87 @@ func (d *DashboardPage) Deactivate() {
 	if d.ctx != nil {
 		d.cancel()
 	}
+
+	d.mutex.Lock()
+	unsubscribe := d.unsubscribe
+	d.unsubscribe = nil
+	d.mutex.Unlock()
+	for _, fn := range unsubscribe {
+		fn()
+	}
 }
 
-// startDataUpdates starts the real-time data update mechanism
+// startDataUpdates follows the connections, config and memory of the core
 func (d *DashboardPage) startDataUpdates() {
-	d.updateSystemInfo()
+	caps := d.controller.Capabilities(d.ctx)
+	d.mutex.Lock()
+	d.caps = caps
+	d.mutex.Unlock()
 
-	go d.startStreamMemoryUsage()
-	// Start periodic updates for other data
-	go d.periodicUpdates()
-}
+	ui.Updater.UpdateUi(d.updateSystemInfo)
 
-// periodicUpdates handles periodic updates for non-streaming data
-func (d *DashboardPage) periodicUpdates() {
-	ticker := time.NewTicker(d.updateInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-d.ctx.Done():
-			return
-		case <-ticker.C:
-			d.updateConnectionsData()
-			d.updateProxyStatusData()
-			d.updateSystemInfo()
-		}
-	}
-}
+	unsubscribe := []func(){
+		d.store.Connections.Subscribe(func(diff *models.ConnectionsDiff) {
+			d.mutex.Lock()
+			d.connectionsData = diff.Connections
+			d.connectionsDiff = diff
+			d.mutex.Unlock()
 
-// updateConnectionsData updates connections data
-func (d *DashboardPage) updateConnectionsData() {
-	connections, err := api.Client.GetConnections()
-	if err != nil {
-		d.connectionsBox.SetText(fmt.Sprintf("[red]获取连接数据失败: %v[white]", err))
-		return
+			ui.Updater.UpdateUi(d.updateConnectionsDisplay)
+		}, func(err error) {
+			ui.Updater.UpdateUi(func() {
+				d.connectionsBox.SetText(fmt.Sprintf("[red]获取连接数据失败: %s[white]", describeError(err)))
+			})
+		}),
+		d.store.Config.Subscribe(func(config *models.Config) {
+			d.mutex.Lock()
+			d.configData = config
+			d.mutex.Unlock()
+
+			ui.Updater.UpdateUi(d.updateControlButtons)
+		}, nil),
+		d.store.Memory.Subscribe(func(memory *models.MemoryUsage) {
+			d.setMemory(memory)
+		}, func(error) {
+			d.setMemory(nil)
+		}),
 	}
 
 	d.mutex.Lock()
-	d.connectionsData = connections
+	d.unsubscribe = unsubscribe
+	d.mutex.Unlock()
+}
+
+// setMemory shows the latest memory sample, nil when the stream is down
+func (d *DashboardPage) setMemory(memory *models.MemoryUsage) {
+	d.mutex.Lock()
+	d.memoryData = memory
 	d.mutex.Unlock()
 
-	d.updateConnectionsDisplay()
+	ui.Updater.UpdateUi(d.updateSystemInfo)
 }
 
 // updateConnectionsDisplay updates the connections display
 func (d *DashboardPage) updateConnectionsDisplay() {
 	d.mutex.RLock()
 	connections := d.connectionsData
+	diff := d.connectionsDiff
 	d.mutex.RUnlock()
 
 	if connections == nil {
 		return
 	}
 
-	totalConnections := len(connections)
-	content := fmt.Sprintf("[green]🔗 总连接数[white] %d", totalConnections)
-	d.connectionsBox.SetText(content)
-}
-
-// updateProxyStatusData updates proxy status data
-func (d *DashboardPage) updateProxyStatusData() {
-	// Get current config
-	config, err := api.Client.GetConfig()
-	if err != nil {
-		log.Printf("Failed to get config: %v", err)
-		return
+	var content strings.Builder
+	fmt.Fprintf(&content, "[green]🔗 总连接数[white] %d", len(connections))
+	if diff != nil {
+		fmt.Fprintf(&content, "  [gray](+%d / -%d)[white]\n", len(diff.Added), len(diff.Closed))
+		fmt.Fprintf(&content, "[yellow]⚡ 实时速度[white] ↑ %s/s  ↓ %s/s\n",
+			utils.FormatBytes(diff.UploadSpeed), utils.FormatBytes(diff.DownloadSpeed))
+		fmt.Fprintf(&content, "[blue]📦 累计流量[white] ↑ %s  ↓ %s",
+			utils.FormatBytes(diff.UploadTotal), utils.FormatBytes(diff.DownloadTotal))
 	}
-
-	d.mutex.Lock()
-	d.configData = config
-	d.mutex.Unlock()
-
-	d.updateControlButtons()
+	d.connectionsBox.SetText(content.String())
 }
 
 // updateControlButtons updates the control buttons based on current config
@@ -310,11 +334,9 @@ func (d *DashboardPage) updateControlButtons() {
 	// Update TUN button
 	tunStatus := "OFF"
 	tunColor := tcell.ColorRed
-	if config.Tun != nil {
-		if enable, ok := config.Tun["enable"].(bool); ok && enable {
-			tunStatus = "ON"
-			tunColor = tcell.ColorGreen
-		}
+	if config.Tun.Enable {
+		tunStatus = "ON"
+		tunColor = tcell.ColorGreen
 	}
 	d.tunBtn.SetBackgroundColor(tunColor)
 	d.tunBtn.SetLabel(fmt.Sprintf("TUN: %s", tunStatus))
@@ -372,27 +394,71 @@ func (d *DashboardPage) updateStatusText(config *models.Config) {
 		contentBuilder.WriteString("  无活动端口")
 	}
 
+	// TUN
+	contentBuilder.WriteString("\n[yellow]🛡️ TUN[white]")
+	tun := config.Tun
+	if !tun.Enable {
+		contentBuilder.WriteString("  未开启")
+	} else {
+		if tun.Stack != "" {
+			fmt.Fprintf(&contentBuilder, " | 栈: %s", tun.Stack)
+		}
+		if tun.Device != "" {
+			fmt.Fprintf(&contentBuilder, " | 设备: %s", tview.Escape(tun.Device))
+		}
+		if tun.MTU != 0 {
+			fmt.Fprintf(&contentBuilder, " | MTU: %d", tun.MTU)
+		}
+		if tun.AutoRoute {
+			contentBuilder.WriteString(" | 自动路由")
+		}
+		if tun.AutoDetectInterface {
+			contentBuilder.WriteString(" | 自动检测接口")
+		}
+		if len(tun.DNSHijack) > 0 {
+			fmt.Fprintf(&contentBuilder, " | DNS 劫持: %s", tview.Escape(strings.Join(tun.DNSHijack, ", ")))
+		}
+	}
+
 	d.statusText.SetText(contentBuilder.String())
 }
 
 // showOperationStatus displays operation result status
 func (d *DashboardPage) showOperationStatus(message string) {
-	d.operationStatus.SetText(message)
+	d.setOperationStatus(message)
+	seq := d.statusSeq
 
-	// Auto-clear status after 3 seconds
+	// Auto-clear status after 3 seconds, unless it was replaced meanwhile
 	go func() {
 		time.Sleep(3 * time.Second)
-		d.operationStatus.SetText("[white]就绪[white]")
+		ui.Updater.UpdateUi(func() {
+			if d.statusSeq == seq {
+				d.operationStatus.SetText("[white]就绪[white]")
+			}
+		})
 	}()
 }
 
+// setOperationStatus displays an operation status until it is replaced
+func (d *DashboardPage) setOperationStatus(message string) {
+	d.statusSeq++
+	d.operationStatus.SetText(message)
+}
+
 // updateSystemInfo updates system information
 func (d *DashboardPage) updateSystemInfo() {
 
 	content := strings.Builder{}
+	d.mutex.RLock()
+	caps := d.caps
+	memory := d.memoryData
+	d.mutex.RUnlock()
+
 	var memUsage string
-	if d.memoryData != nil {
-		memUsage = utils.FormatBytes(d.memoryData.Inuse)
+	if memory != nil {
+		memUsage = utils.FormatBytes(memory.Inuse)
+	} else if !caps.Has(api.FeatureMemory) {
+		memUsage = "[gray]核心不支持[white]"
 	} else {
 		memUsage = "-"
 	}
@@ -408,18 +474,9 @@ func (d *DashboardPage) Stop() {
 	}
 }
 
-// Refresh manually refreshes all data
+// Refresh fetches the config again; connections and memory are streamed
 func (d *DashboardPage) Refresh() {
-	go func() {
-		d.updateConnectionsData()
-		d.updateProxyStatusData()
-		d.updateSystemInfo()
-	}()
-}
-
-// SetUpdateInterval sets the update interval for periodic updates
-func (d *DashboardPage) SetUpdateInterval(interval time.Duration) {
-	d.updateInterval = interval
+	d.store.Config.Refresh()
 }
 
 // GetInputCapture returns the input capture function for keyboard shortcuts
@@ -434,26 +491,6 @@ func (d *DashboardPage) GetInputCapture() func(event *tcell.EventKey) *tcell.Eve
 	}
 }
 
-// startStreamMemoryUsage starts streaming memory data
-func (d *DashboardPage) startStreamMemoryUsage() {
-	for {
-		select {
-		case <-d.ctx.Done():
-			return
-		default:
-			err := api.StreamClient.StreamMemoryUsage(d.ctx, func(memory *models.MemoryUsage) {
-				d.memoryData = memory
-			})
-
-			if err != nil && err != context.Canceled {
-				// Connection lost, wait and retry
-				d.memoryData = nil
-				time.Sleep(5 * time.Second)
-			}
-		}
-	}
-}
-
 // toggleAllowLan toggles the Allow LAN setting
 func (d *DashboardPage) toggleAllowLan() {
 	d.mutex.RLock()
@@ -468,28 +505,26 @@ func (d *DashboardPage) toggleAllowLan() {
 	// Show operation in progress
 	d.showOperationStatus("[yellow]正在切换 AllowLAN...[white]")
 
-	// Create a new config with toggled Allow LAN setting
-	newConfig := *config // Copy existing config
-	newConfig.AllowLan = !config.AllowLan
-
-	// Update the setting via API
-	err := api.Client.UpdateConfig(&newConfig)
+	// Only allow-lan is sent, so nothing else the core has changed since
+	// the last refresh is overwritten
+	allowLan := !config.AllowLan
+	err := d.controller.SetAllowLan(d.ctx, allowLan)
 
 	if err != nil {
-		d.showOperationStatus(fmt.Sprintf("[red]AllowLAN 切换失败: %v[white]", err))
-		log.Printf("Failed to toggle Allow LAN: %v", err)
+		d.showOperationStatus(fmt.Sprintf("[red]AllowLAN 切换失败: %s[white]", describeError(err)))
+		slog.Warn("Failed to toggle Allow LAN", "page", "dashboard", "err", err)
 		return
 	}
 
 	status := "关闭"
-	if newConfig.AllowLan {
+	if allowLan {
 		status = "开启"
 	}
 	d.showOperationStatus(fmt.Sprintf("[green]AllowLAN 已%s[white]", status))
-	log.Printf("Allow LAN toggled to: %v", newConfig.AllowLan)
+	slog.Info("Allow LAN toggled", "page", "dashboard", "allow_lan", allowLan)
 
-	// Refresh data to show updated status
-	go d.updateProxyStatusData()
+	// Show the updated config on every page
+	d.store.Config.Refresh()
 }
 
 // toggleTun toggles the TUN mode setting
@@ -506,30 +541,13 @@ func (d *DashboardPage) toggleTun() {
 	// Show operation in progress
 	d.showOperationStatus("[yellow]正在切换 TUN...[white]")
 
-	// Create a new config with toggled TUN setting
-	newConfig := *config // Copy existing config
-
-	// Initialize Tun map if it's nil
-	if newConfig.Tun == nil {
-		newConfig.Tun = make(map[string]interface{})
-	}
-
-	// Get current TUN state
-	currentTunEnabled := false
-	if enable, ok := newConfig.Tun["enable"].(bool); ok {
-		currentTunEnabled = enable
-	}
-
-	// Toggle TUN state
-	newTunEnabled := !currentTunEnabled
-	newConfig.Tun["enable"] = newTunEnabled
-
-	// Update the setting via API
-	err := api.Client.UpdateConfig(&newConfig)
+	// Toggle TUN state, leaving the rest of the tun section to the core
+	newTunEnabled := !config.Tun.Enable
+	err := d.controller.SetTunEnabled(d.ctx, newTunEnabled)
 
 	if err != nil {
-		d.showOperationStatus(fmt.Sprintf("[red]TUN 切换失败: %v[white]", err))
-		log.Printf("Failed to toggle TUN: %v", err)
+		d.showOperationStatus(fmt.Sprintf("[red]TUN 切换失败: %s[white]", describeError(err)))
+		slog.Warn("Failed to toggle TUN", "page", "dashboard", "err", err)
 		return
 	}
 
@@ -538,8 +556,8 @@ func (d *DashboardPage) toggleTun() {
 		status = "开启"
 	}
 	d.showOperationStatus(fmt.Sprintf("[green]TUN 已%s[white]", status))
-	log.Printf("TUN toggled to: %v", newTunEnabled)
+	slog.Info("TUN toggled", "page", "dashboard", "tun", newTunEnabled)
 
-	// Refresh data to show updated status
-	go d.updateProxyStatusData()
+	// Show the updated config on every page
+	d.store.Config.Refresh()
 }
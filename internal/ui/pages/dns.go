@@ -0,0 +1,464 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxDNSHistory is the number of recent queries kept in the history list
+const maxDNSHistory = 30
+
+// dnsQueryTypes are the record types offered in the type dropdown
+var dnsQueryTypes = []string{"A", "AAAA", "CNAME", "TXT", "MX", "NS", "SOA", "PTR", "SRV", "HTTPS", "CAA"}
+
+// dnsTypeNames maps DNS record type numbers to names
+var dnsTypeNames = map[uint16]string{
+	1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 15: "MX", 16: "TXT",
+	28: "AAAA", 33: "SRV", 64: "SVCB", 65: "HTTPS", 257: "CAA",
+}
+
+// dnsRcodeNames maps DNS response codes to names
+var dnsRcodeNames = map[int]string{
+	0: "NOERROR", 1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED",
+}
+
+// DNS represents the DNS query page
+type DNS struct {
+	*DNSPage
+}
+
+// Activate activates the DNS page
+func (d *DNS) Activate() {
+	slog.Debug("Activating page", "page", "dns")
+	d.DNSPage.Activate()
+}
+
+// Deactivate deactivates the DNS page
+func (d *DNS) Deactivate() {
+	slog.Debug("Deactivating page", "page", "dns")
+	d.DNSPage.Deactivate()
+}
+
+// dnsQuery is a finished query kept in the history
+type dnsQuery struct {
+	name      string
+	queryType string
+	time      time.Time
+	latency   time.Duration
+	result    *models.DNSQueryResult
+	err       error
+}
+
+// DNSPage represents the DNS query tool page
+type DNSPage struct {
+	*tview.Flex
+	controller api.Controller
+
+	// Components
+	domainInput  *tview.InputField
+	typeDropDown *tview.DropDown
+	queryButton  *tview.Button
+	resultTable  *tview.Table
+	historyList  *tview.List
+	statusText   *tview.TextView
+
+	// Data
+	history []dnsQuery // Newest first
+
+	// Control
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.RWMutex
+
+	// State
+	isQuerying  bool
+	unsupported string // Set when the core has no DNS query API
+
+	// Navigation
+	focusableComponents []tview.Primitive
+	currentFocusIndex   int
+}
+
+// NewDNSPage creates a new DNS query page
+func NewDNSPage(controller api.Controller) *DNSPage {
+	page := &DNSPage{
+		Flex:       tview.NewFlex(),
+		controller: controller,
+	}
+
+	page.setupLayout()
+	page.setupEventHandlers()
+
+	return page
+}
+
+// Activate redraws the history when the page becomes active.
+// History is kept across activations on purpose.
+func (d *DNSPage) Activate() {
+	d.mutex.Lock()
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	d.mutex.Unlock()
+
+	unsupported := ""
+	if caps := d.controller.Capabilities(d.ctx); !caps.Has(api.FeatureDNSQuery) {
+		unsupported = describeUnsupported(caps, api.FeatureDNSQuery)
+	}
+	d.mutex.Lock()
+	d.unsupported = unsupported
+	d.mutex.Unlock()
+
+	go ui.Updater.UpdateUi(func() {
+		d.updateHistoryList()
+		d.domainInput.SetDisabled(unsupported != "")
+		d.queryButton.SetDisabled(unsupported != "")
+		if unsupported != "" {
+			d.statusText.SetText(fmt.Sprintf("[yellow]%s[white]", unsupported))
+		}
+	})
+}
+
+// Deactivate deactivates the DNS page
+func (d *DNSPage) Deactivate() {
+	// Abort a query still in flight
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// setupLayout sets up the DNS page layout
+func (d *DNSPage) setupLayout() {
+	d.createQueryBar()
+	d.createResultTable()
+	d.createHistoryList()
+	d.createStatusText()
+
+	d.initializeNavigation()
+
+	// Query bar (domain + type + button)
+	queryBar := tview.NewFlex().SetDirection(tview.FlexColumn)
+	queryBar.SetBorder(true)
+	queryBar.SetTitle(" 查询 ")
+	queryBar.AddItem(d.domainInput, 0, 3, true)
+	queryBar.AddItem(d.typeDropDown, 16, 0, false)
+	queryBar.AddItem(d.queryButton, 8, 0, false)
+
+	// Left panel (query bar + results + status)
+	leftPanel := tview.NewFlex().SetDirection(tview.FlexRow)
+	leftPanel.AddItem(queryBar, 3, 0, true)
+	leftPanel.AddItem(d.resultTable, 0, 1, false)
+	leftPanel.AddItem(d.statusText, 4, 0, false)
+
+	d.SetDirection(tview.FlexColumn)
+	d.AddItem(leftPanel, 0, 3, true)
+	d.AddItem(d.historyList, 40, 0, false)
+
+	d.SetBorder(true)
+	d.SetTitle(" DNS 查询 ")
+}
+
+// createQueryBar creates the domain input, type dropdown and query button
+func (d *DNSPage) createQueryBar() {
+	d.domainInput = tview.NewInputField().
+		SetLabel("域名: ").
+		SetPlaceholder("example.com")
+
+	d.typeDropDown = tview.NewDropDown().
+		SetLabel(" 类型: ").
+		SetOptions(dnsQueryTypes, nil).
+		SetCurrentOption(0)
+
+	d.queryButton = tview.NewButton("查询")
+	d.queryButton.SetSelectedFunc(d.runQuery)
+}
+
+// createResultTable creates the answer records table
+func (d *DNSPage) createResultTable() {
+	d.resultTable = tview.NewTable().SetFixed(1, 0)
+	d.resultTable.SetBorder(true)
+	d.resultTable.SetTitle(" 解析结果 ")
+	d.resultTable.SetSelectable(true, false)
+	d.setTableHeaders()
+}
+
+// setTableHeaders sets the result table headers
+func (d *DNSPage) setTableHeaders() {
+	headers := []string{"区段", "名称", "类型", "TTL", "数据"}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false)
+		d.resultTable.SetCell(0, i, cell)
+	}
+}
+
+// createHistoryList creates the recent queries list
+func (d *DNSPage) createHistoryList() {
+	d.historyList = tview.NewList()
+	d.historyList.SetBorder(true)
+	d.historyList.SetTitle(" 查询历史 ")
+}
+
+// createStatusText creates the status display
+func (d *DNSPage) createStatusText() {
+	d.statusText = tview.NewTextView()
+	d.statusText.SetBorder(true)
+	d.statusText.SetTitle(" 状态 ")
+	d.statusText.SetDynamicColors(true)
+	d.statusText.SetText("[gray]输入域名后按 Enter 查询 | TAB 切换组件[white]")
+}
+
+// setupEventHandlers sets up event handlers
+func (d *DNSPage) setupEventHandlers() {
+	d.domainInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			d.runQuery()
+		}
+	})
+
+	// Selecting a history entry shows its stored result
+	d.historyList.SetChangedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		d.mutex.RLock()
+		if index < 0 || index >= len(d.history) {
+			d.mutex.RUnlock()
+			return
+		}
+		query := d.history[index]
+		d.mutex.RUnlock()
+
+		d.showQuery(query)
+	})
+
+	// Enter on a history entry repeats the query
+	d.historyList.SetSelectedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		d.mutex.RLock()
+		if index < 0 || index >= len(d.history) {
+			d.mutex.RUnlock()
+			return
+		}
+		query := d.history[index]
+		d.mutex.RUnlock()
+
+		d.domainInput.SetText(query.name)
+		d.typeDropDown.SetCurrentOption(dnsTypeIndex(query.queryType))
+		d.runQuery()
+	})
+}
+
+// runQuery resolves the entered domain in the background
+func (d *DNSPage) runQuery() {
+	name := strings.TrimSpace(d.domainInput.GetText())
+	_, queryType := d.typeDropDown.GetCurrentOption()
+	if name == "" {
+		d.statusText.SetText("[red]请输入要查询的域名[white]")
+		return
+	}
+
+	d.mutex.Lock()
+	if d.unsupported != "" {
+		d.statusText.SetText(fmt.Sprintf("[yellow]%s[white]", d.unsupported))
+		d.mutex.Unlock()
+		return
+	}
+	if d.isQuerying {
+		d.mutex.Unlock()
+		return
+	}
+	d.isQuerying = true
+	d.mutex.Unlock()
+
+	d.statusText.SetText(fmt.Sprintf("[yellow]正在查询 %s %s...[white]", tview.Escape(name), queryType))
+
+	go func() {
+		start := time.Now()
+		result, err := d.controller.QueryDNS(d.ctx, name, queryType)
+		query := dnsQuery{
+			name:      name,
+			queryType: queryType,
+			time:      start,
+			latency:   time.Since(start),
+			result:    result,
+			err:       err,
+		}
+		if errors.Is(err, context.Canceled) {
+			// Left the page mid-query; nothing worth keeping
+			d.mutex.Lock()
+			d.isQuerying = false
+			d.mutex.Unlock()
+			return
+		}
+		if err != nil {
+			slog.Warn("DNS query failed", "page", "dns", "name", name, "type", queryType, "err", err)
+		}
+
+		d.mutex.Lock()
+		d.isQuerying = false
+		d.history = append([]dnsQuery{query}, d.history...)
+		if len(d.history) > maxDNSHistory {
+			d.history = d.history[:maxDNSHistory]
+		}
+		d.mutex.Unlock()
+
+		ui.Updater.UpdateUi(func() {
+			d.updateHistoryList()
+			d.showQuery(query)
+		})
+	}()
+}
+
+// showQuery renders a query's records and status
+func (d *DNSPage) showQuery(query dnsQuery) {
+	d.resultTable.Clear()
+	d.setTableHeaders()
+
+	if query.err != nil {
+		d.statusText.SetText(fmt.Sprintf("[red]查询 %s %s 失败:[white] %s", tview.Escape(query.name), query.queryType, tview.Escape(describeError(query.err))))
+		return
+	}
+
+	result := query.result
+	row := 1
+	sections := []struct {
+		label   string
+		records []models.DNSRecord
+	}{
+		{"Answer", result.Answer},
+		{"Authority", result.Authority},
+		{"Additional", result.Additional},
+	}
+	for _, section := range sections {
+		for _, record := range section.records {
+			cells := []struct {
+				text  string
+				color tcell.Color
+				align int
+			}{
+				{section.label, tcell.ColorGray, tview.AlignLeft},
+				{tview.Escape(record.Name), tcell.ColorWhite, tview.AlignLeft},
+				{dnsTypeName(record.Type), tcell.ColorLightBlue, tview.AlignCenter},
+				{fmt.Sprintf("%d", record.TTL), tcell.ColorYellow, tview.AlignRight},
+				{tview.Escape(strings.TrimSpace(record.Data)), tcell.ColorGreen, tview.AlignLeft},
+			}
+			for col, cellData := range cells {
+				d.resultTable.SetCell(row, col, tview.NewTableCell(cellData.text).
+					SetTextColor(cellData.color).
+					SetAlign(cellData.align))
+			}
+			row++
+		}
+	}
+
+	rcodeColor := "green"
+	if result.Status != 0 {
+		rcodeColor = "red"
+	}
+	d.statusText.SetText(fmt.Sprintf("%s %s | 状态: [%s]%s[white] | %d 条应答 | 耗时 %s\n标志: %s",
+		tview.Escape(query.name), query.queryType, rcodeColor, dnsRcodeName(result.Status), len(result.Answer),
+		query.latency.Truncate(time.Millisecond), dnsFlags(result)))
+}
+
+// updateHistoryList redraws the recent queries list
+func (d *DNSPage) updateHistoryList() {
+	d.mutex.RLock()
+	history := d.history
+	d.mutex.RUnlock()
+
+	d.historyList.Clear()
+	for _, query := range history {
+		summary := "[red]失败[white]"
+		if query.err == nil {
+			summary = fmt.Sprintf("%s · %d 条", dnsRcodeName(query.result.Status), len(query.result.Answer))
+		}
+		d.historyList.AddItem(
+			fmt.Sprintf("%s %s", query.queryType, tview.Escape(query.name)),
+			fmt.Sprintf("%s %s", query.time.Format("15:04:05"), summary),
+			0, nil)
+	}
+}
+
+// dnsTypeName returns the name of a DNS record type
+func dnsTypeName(recordType uint16) string {
+	if name, ok := dnsTypeNames[recordType]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", recordType)
+}
+
+// dnsRcodeName returns the name of a DNS response code
+func dnsRcodeName(rcode int) string {
+	if name, ok := dnsRcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// dnsTypeIndex returns the dropdown index of a query type
+func dnsTypeIndex(queryType string) int {
+	for i, t := range dnsQueryTypes {
+		if t == queryType {
+			return i
+		}
+	}
+	return 0
+}
+
+// dnsFlags formats the header flags that are set
+func dnsFlags(result *models.DNSQueryResult) string {
+	flags := make([]string, 0, 5)
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"RD", result.RD}, {"RA", result.RA}, {"TC", result.TC}, {"AD", result.AD}, {"CD", result.CD},
+	} {
+		if flag.set {
+			flags = append(flags, flag.name)
+		}
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, " ")
+}
+
+// Navigation methods
+
+// initializeNavigation initializes the navigation system
+func (d *DNSPage) initializeNavigation() {
+	d.focusableComponents = []tview.Primitive{d.domainInput, d.typeDropDown, d.queryButton, d.resultTable, d.historyList}
+	d.currentFocusIndex = 0
+	d.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTAB:
+			d.switchToNextComponent()
+			return nil
+		case tcell.KeyBacktab:
+			d.switchToPrevComponent()
+			return nil
+		}
+		return event
+	})
+}
+
+// switchToNextComponent switches focus to the next component
+func (d *DNSPage) switchToNextComponent() {
+	d.currentFocusIndex = (d.currentFocusIndex + 1) % len(d.focusableComponents)
+	ui.Updater.SetFocus(d.focusableComponents[d.currentFocusIndex])
+}
+
+// switchToPrevComponent switches focus to the previous component
+func (d *DNSPage) switchToPrevComponent() {
+	d.currentFocusIndex = (d.currentFocusIndex - 1 + len(d.focusableComponents)) % len(d.focusableComponents)
+	ui.Updater.SetFocus(d.focusableComponents[d.currentFocusIndex])
+}
@@ -0,0 +1,114 @@
+package pages
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"mihomoTui/internal/api"
+)
+
+// describeError turns an API failure into a message the user can act on
+func describeError(err error) string {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+
+	switch apiErr.Kind {
+	case api.KindUnauthorized:
+		return "密钥错误或缺失，请在配置页检查 API 密钥"
+	case api.KindNotFound:
+		if apiErr.Message != "" {
+			return fmt.Sprintf("未找到: %s", apiErr.Message)
+		}
+		return fmt.Sprintf("未找到: %s", apiErr.Endpoint)
+	case api.KindTimeout:
+		return "请求超时，核心未及时响应"
+	case api.KindUnreachable:
+		return "无法连接核心，请确认核心正在运行且 API 地址正确"
+	case api.KindTLS:
+		return fmt.Sprintf("TLS 握手失败: %s", describeTLSError(apiErr.Err))
+	case api.KindSSH:
+		return fmt.Sprintf("SSH 隧道失败: %s", describeSSHError(apiErr.Err))
+	case api.KindBadRequest:
+		return fmt.Sprintf("请求被拒绝: %s", messageOrStatus(apiErr))
+	case api.KindServer:
+		return fmt.Sprintf("核心内部错误: %s", messageOrStatus(apiErr))
+	default:
+		return apiErr.Error()
+	}
+}
+
+// describeTLSError explains the common ways a TLS handshake goes wrong
+func describeTLSError(err error) string {
+	var (
+		pinErr      *api.PinMismatchError
+		configErr   *api.TLSConfigError
+		unknownCA   x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+	)
+	switch {
+	case errors.As(err, &configErr):
+		return fmt.Sprintf("TLS 配置无效: %v", configErr.Err)
+	case errors.As(err, &pinErr):
+		return fmt.Sprintf("证书指纹不匹配，实际为 %s", pinErr.Got)
+	case errors.As(err, &unknownCA):
+		return "证书不受信任，请配置 CA 证书或证书指纹"
+	case errors.As(err, &hostnameErr):
+		return fmt.Sprintf("证书与地址不匹配: %v", hostnameErr)
+	case api.IsPlainHTTP(err):
+		return "对端不是 TLS 服务，请改用 http:// 地址"
+	case api.IsHandshakeAlert(err):
+		return "核心中止了握手，请检查客户端证书"
+	default:
+		return err.Error()
+	}
+}
+
+// describeSSHError explains why the SSH tunnel could not be opened
+func describeSSHError(err error) string {
+	var sshErr *api.SSHError
+	if !errors.As(err, &sshErr) {
+		return err.Error()
+	}
+
+	switch sshErr.Failure {
+	case api.SSHHostUnknown:
+		return fmt.Sprintf("%s 不在 known_hosts 中，请先用 ssh 连接一次以确认主机密钥", sshErr.Host)
+	case api.SSHHostKeyMismatch:
+		return fmt.Sprintf("%s 的主机密钥与 known_hosts 不一致", sshErr.Host)
+	case api.SSHAuthFailed:
+		return "认证失败，请检查 SSH 用户和密钥"
+	case api.SSHConfigInvalid:
+		return fmt.Sprintf("SSH 配置无效: %v", sshErr.Err)
+	default:
+		return fmt.Sprintf("无法连接 %s: %v", sshErr.Host, sshErr.Err)
+	}
+}
+
+// featureNames names optional core features in messages
+var featureNames = map[api.Feature]string{
+	api.FeatureProxyProviders: "代理订阅",
+	api.FeatureRuleProviders:  "规则集",
+	api.FeatureGroupDelay:     "组延迟测试",
+	api.FeatureMemory:         "内存统计",
+	api.FeatureDNSQuery:       "DNS 查询",
+	api.FeatureRestart:        "重启核心",
+	api.FeatureGeoUpdate:      "更新 GEO 数据库",
+	api.FeatureFlushFakeIP:    "清空 FakeIP 缓存",
+	api.FeatureFlushDNS:       "清空 DNS 缓存",
+}
+
+// describeUnsupported explains that the connected core lacks a feature
+func describeUnsupported(caps api.Capabilities, feature api.Feature) string {
+	return fmt.Sprintf("当前核心 (%s) 不支持%s", caps.Core, featureNames[feature])
+}
+
+// messageOrStatus prefers the core's own message over the bare status code
+func messageOrStatus(apiErr *api.APIError) string {
+	if apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return fmt.Sprintf("状态码 %d", apiErr.StatusCode)
+}
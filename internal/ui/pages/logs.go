@@ -3,7 +3,7 @@ package pages
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -20,20 +20,21 @@ type Logs struct {
 
 // Activate activates the logs page and starts streaming
 func (l *Logs) Activate() {
-	log.Printf("Activating logs page")
+	slog.Debug("Activating page", "page", "logs")
 	l.LogsPage.Activate()
 }
 
 // Deactivate deactivates the logs page and stops streaming
 func (l *Logs) Deactivate() {
-	log.Printf("Deactivating logs page")
+	slog.Debug("Deactivating page", "page", "logs")
 	l.LogsPage.Deactivate()
 }
 
 // LogsPage represents the logs page with streaming support
 type LogsPage struct {
 	*tview.Flex
-	textView *tview.TextView
+	controller api.Controller
+	textView   *tview.TextView
 
 	// Streaming control
 	ctx    context.Context
@@ -46,12 +47,13 @@ type LogsPage struct {
 }
 
 // NewLogsPage creates a new logs page with streaming support
-func NewLogsPage() *LogsPage {
+func NewLogsPage(controller api.Controller) *LogsPage {
 	page := &LogsPage{
-		Flex:     tview.NewFlex(),
-		textView: tview.NewTextView(),
-		logs:     make([]string, 0),
-		maxLines: 1000, // Keep last 1000 lines
+		Flex:       tview.NewFlex(),
+		controller: controller,
+		textView:   tview.NewTextView(),
+		logs:       make([]string, 0),
+		maxLines:   1000, // Keep last 1000 lines
 	}
 
 	page.setupUI()
@@ -80,21 +82,14 @@ func (p *LogsPage) setupUI() {
 
 // startLogStream starts streaming logs from the API
 func (p *LogsPage) startLogStream() {
-	go func() {
-		for {
-			select {
-			case <-p.ctx.Done():
-				return
-			default:
-				err := api.StreamClient.StreamLogs(p.ctx, p.onLogReceived)
-				if err != nil && err != context.Canceled {
-					// Connection lost, show error and retry
-					p.addLog(fmt.Sprintf("[red]连接错误: %v[white]", err))
-					time.Sleep(5 * time.Second)
-				}
-			}
-		}
-	}()
+	go api.Supervise(p.ctx, "logs", func(ctx context.Context, alive func()) error {
+		return p.controller.StreamLogs(ctx, func(log *models.Log) {
+			alive()
+			p.onLogReceived(log)
+		})
+	}, func(err error) {
+		p.addLog(fmt.Sprintf("[red]连接错误: %s[white]", describeError(err)))
+	})
 }
 
 // onLogReceived handles incoming log messages
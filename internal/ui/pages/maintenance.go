@@ -0,0 +1,191 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/ui"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maintenanceModal is the overlay name used by the maintenance panel
+const maintenanceModal = "maintenance"
+
+// maintenanceAction describes a core maintenance operation
+type maintenanceAction struct {
+	label    string        // Menu entry, also used in progress messages
+	confirm  string        // Question shown before running
+	needPath bool          // Asks for a config path before running
+	requires []api.Feature // Core features the action needs
+	run      func(ctx context.Context, controller api.Controller, path string) error
+}
+
+// availableActions returns the maintenance actions the core supports
+func availableActions(caps api.Capabilities) []maintenanceAction {
+	var available []maintenanceAction
+	for _, action := range maintenanceActions() {
+		supported := true
+		for _, feature := range action.requires {
+			supported = supported && caps.Has(feature)
+		}
+		if supported {
+			available = append(available, action)
+		}
+	}
+	return available
+}
+
+// maintenanceActions returns the operations offered by the maintenance panel
+func maintenanceActions() []maintenanceAction {
+	return []maintenanceAction{
+		{
+			label:    "重载配置",
+			confirm:  "从文件重新加载配置，留空则重载核心当前使用的配置文件",
+			needPath: true,
+			run: func(ctx context.Context, controller api.Controller, path string) error {
+				return controller.ReloadConfig(ctx, path)
+			},
+		},
+		{
+			label:    "重启核心",
+			confirm:  "确定要重启核心吗？所有连接将被中断",
+			requires: []api.Feature{api.FeatureRestart},
+			run: func(ctx context.Context, controller api.Controller, _ string) error {
+				return controller.Restart(ctx)
+			},
+		},
+		{
+			label:    "清空 FakeIP 缓存",
+			confirm:  "确定要清空 FakeIP 缓存吗？",
+			requires: []api.Feature{api.FeatureFlushFakeIP},
+			run: func(ctx context.Context, controller api.Controller, _ string) error {
+				return controller.FlushFakeIPCache(ctx)
+			},
+		},
+		{
+			label:    "清空 DNS 缓存",
+			confirm:  "确定要清空 DNS 缓存吗？",
+			requires: []api.Feature{api.FeatureFlushDNS},
+			run: func(ctx context.Context, controller api.Controller, _ string) error {
+				return controller.FlushDNSCache(ctx)
+			},
+		},
+		{
+			label:    "更新 GEO 数据库",
+			confirm:  "确定要下载最新的 GeoIP / GeoSite 数据库吗？",
+			requires: []api.Feature{api.FeatureGeoUpdate},
+			run: func(ctx context.Context, controller api.Controller, _ string) error {
+				return controller.UpdateGeoData(ctx)
+			},
+		},
+	}
+}
+
+// showMaintenancePanel opens the list of maintenance actions
+func (d *DashboardPage) showMaintenancePanel() {
+	d.mutex.RLock()
+	actions := availableActions(d.caps)
+	d.mutex.RUnlock()
+
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle(" 维护 ")
+	for _, action := range actions {
+		list.AddItem(action.label, "[gray]"+action.confirm, 0, func() {
+			ui.Updater.HideModal(maintenanceModal)
+			d.confirmMaintenance(action)
+		})
+	}
+	list.AddItem("关闭", "", 0, func() {
+		ui.Updater.HideModal(maintenanceModal)
+	})
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			ui.Updater.HideModal(maintenanceModal)
+			return nil
+		}
+		return event
+	})
+
+	ui.Updater.ShowModal(maintenanceModal, ui.Centered(list, 64, 2*(len(actions)+1)+2))
+}
+
+// confirmMaintenance asks for confirmation (and a path, if needed) before running an action
+func (d *DashboardPage) confirmMaintenance(action maintenanceAction) {
+	if action.needPath {
+		form := tview.NewForm()
+		form.SetBorder(true)
+		form.SetTitle(fmt.Sprintf(" %s ", action.label))
+		form.AddTextView("", action.confirm, 0, 2, true, false)
+		form.AddInputField("配置路径", "", 50, nil, nil)
+		form.AddButton("确定", func() {
+			path := strings.TrimSpace(form.GetFormItemByLabel("配置路径").(*tview.InputField).GetText())
+			ui.Updater.HideModal(maintenanceModal)
+			d.runMaintenance(action, path)
+		})
+		form.AddButton("取消", func() {
+			ui.Updater.HideModal(maintenanceModal)
+		})
+		form.SetCancelFunc(func() {
+			ui.Updater.HideModal(maintenanceModal)
+		})
+
+		ui.Updater.ShowModal(maintenanceModal, ui.Centered(form, 72, 11))
+		return
+	}
+
+	modal := tview.NewModal().
+		SetText(action.confirm).
+		AddButtons([]string{"确定", "取消"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			ui.Updater.HideModal(maintenanceModal)
+			if buttonLabel == "确定" {
+				d.runMaintenance(action, "")
+			}
+		})
+
+	ui.Updater.ShowModal(maintenanceModal, modal)
+}
+
+// runMaintenance runs an action in the background, reporting progress and
+// the result in the operation status box
+func (d *DashboardPage) runMaintenance(action maintenanceAction, path string) {
+	d.mutex.Lock()
+	if d.maintenanceRunning {
+		d.mutex.Unlock()
+		d.showOperationStatus("[yellow]已有维护操作正在进行[white]")
+		return
+	}
+	d.maintenanceRunning = true
+	d.mutex.Unlock()
+
+	d.setOperationStatus(fmt.Sprintf("[yellow]正在%s...[white]", action.label))
+
+	go func() {
+		err := action.run(d.ctx, d.controller, path)
+
+		d.mutex.Lock()
+		d.maintenanceRunning = false
+		d.mutex.Unlock()
+
+		if err != nil {
+			slog.Warn("Maintenance action failed", "page", "dashboard", "action", action.label, "err", err)
+			ui.Updater.UpdateUi(func() {
+				d.showOperationStatus(fmt.Sprintf("[red]%s失败: %s[white]", action.label, describeError(err)))
+			})
+			return
+		}
+
+		slog.Info("Maintenance action completed", "page", "dashboard", "action", action.label)
+		ui.Updater.UpdateUi(func() {
+			d.showOperationStatus(fmt.Sprintf("[green]%s完成[white]", action.label))
+		})
+
+		// Reloads and restarts can change what the control panel shows
+		d.store.Config.Refresh()
+	}()
+}
@@ -0,0 +1,394 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+	"mihomoTui/internal/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// overviewPollTimeout bounds each status poll, so one slow core doesn't
+// hold its row back for the full client timeout
+const overviewPollTimeout = 5 * time.Second
+
+// Overview represents the multi-core overview page
+type Overview struct {
+	*OverviewPage
+}
+
+// Activate activates the overview page
+func (o *Overview) Activate() {
+	slog.Debug("Activating page", "page", "overview")
+	o.OverviewPage.Activate()
+}
+
+// Deactivate deactivates the overview page
+func (o *Overview) Deactivate() {
+	slog.Debug("Deactivating page", "page", "overview")
+	o.OverviewPage.Deactivate()
+}
+
+// coreStatus is the latest state of one controller
+type coreStatus struct {
+	profile     config.Profile
+	checked     bool  // At least one poll has finished
+	err         error // Error of the last poll, nil when reachable
+	version     string
+	config      *models.Config
+	traffic     *models.Traffic
+	connections int
+	memory      int64
+}
+
+// OverviewPage watches every configured controller at once
+type OverviewPage struct {
+	*tview.Flex
+	configManager *config.Manager
+
+	// Components
+	coresTable *tview.Table
+	detailText *tview.TextView
+
+	// Data
+	cores   []*coreStatus
+	clients []*api.HttpClient
+
+	// Control
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.RWMutex
+
+	// Called with the profile name when a core is opened
+	onSelect func(name string)
+
+	// Update frequency
+	updateInterval time.Duration
+}
+
+// NewOverviewPage creates a new overview page
+func NewOverviewPage(configManager *config.Manager, onSelect func(name string)) *OverviewPage {
+	page := &OverviewPage{
+		Flex:           tview.NewFlex(),
+		configManager:  configManager,
+		onSelect:       onSelect,
+		updateInterval: 3 * time.Second,
+	}
+
+	page.setupLayout()
+	return page
+}
+
+// Activate connects to every profile and starts watching it
+func (o *OverviewPage) Activate() {
+	profiles := o.configManager.Get().Profiles
+
+	o.mutex.Lock()
+	o.ctx, o.cancel = context.WithCancel(context.Background())
+	o.cores = make([]*coreStatus, len(profiles))
+	o.clients = make([]*api.HttpClient, len(profiles))
+	for i, profile := range profiles {
+		o.cores[i] = &coreStatus{profile: profile}
+		// No client timeout, as the traffic stream shares the client
+		o.clients[i] = api.NewHttpClient(profile.API, 0)
+	}
+	ctx := o.ctx
+	cores := o.cores
+	clients := o.clients
+	o.mutex.Unlock()
+
+	ui.Updater.UpdateUi(o.updateTable)
+
+	for i, client := range clients {
+		go o.pollCore(ctx, i, cores[i], client)
+		go o.watchTraffic(ctx, i, cores[i], client)
+	}
+}
+
+// Deactivate stops watching and closes the connections to every core
+func (o *OverviewPage) Deactivate() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.cancel != nil {
+		o.cancel()
+	}
+	for _, client := range o.clients {
+		client.Close()
+	}
+	o.clients = nil
+}
+
+// setupLayout sets up the overview page layout
+func (o *OverviewPage) setupLayout() {
+	o.coresTable = tview.NewTable().SetFixed(1, 0)
+	o.coresTable.SetBorder(true)
+	o.coresTable.SetTitle(" 核心 ")
+	o.coresTable.SetSelectable(true, false)
+	o.coresTable.SetSelectedFunc(func(row, column int) {
+		if core := o.coreAt(row); core != nil && o.onSelect != nil {
+			o.onSelect(core.profile.Name)
+		}
+	})
+	o.coresTable.SetSelectionChangedFunc(func(row, column int) {
+		o.updateDetail()
+	})
+	o.coresTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlR {
+			o.Refresh()
+			return nil
+		}
+		switch event.Rune() {
+		case 'r', 'R':
+			o.Refresh()
+			return nil
+		}
+		return event
+	})
+
+	o.detailText = tview.NewTextView()
+	o.detailText.SetBorder(true)
+	o.detailText.SetTitle(" 详情 ")
+	o.detailText.SetDynamicColors(true)
+	o.detailText.SetWrap(true)
+
+	o.SetDirection(tview.FlexRow)
+	o.AddItem(o.coresTable, 0, 1, true)
+	o.AddItem(o.detailText, 7, 0, false)
+
+	o.SetBorder(true)
+	o.SetTitle(" 总览 ")
+}
+
+// Refresh polls every core again without waiting for the next tick
+func (o *OverviewPage) Refresh() {
+	o.mutex.RLock()
+	ctx := o.ctx
+	cores := o.cores
+	clients := o.clients
+	o.mutex.RUnlock()
+
+	for i, client := range clients {
+		go o.refreshCore(ctx, i, cores[i], client)
+	}
+}
+
+// pollCore refreshes a core's status until the page is deactivated. Cores
+// are passed by pointer, so late results never land in a newer activation.
+func (o *OverviewPage) pollCore(ctx context.Context, index int, core *coreStatus, client api.Controller) {
+	ticker := time.NewTicker(o.updateInterval)
+	defer ticker.Stop()
+
+	for {
+		o.refreshCore(ctx, index, core, client)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshCore fetches the version, config and connections of a core
+func (o *OverviewPage) refreshCore(ctx context.Context, index int, core *coreStatus, client api.Controller) {
+	o.mutex.RLock()
+	needVersion := core.version == "" || core.err != nil
+	o.mutex.RUnlock()
+
+	pollCtx, cancel := context.WithTimeout(ctx, overviewPollTimeout)
+	defer cancel()
+
+	// The version only changes across restarts, which show up as errors
+	var version *models.Version
+	cfg, err := client.GetConfig(pollCtx)
+	if err == nil && needVersion {
+		version, err = client.GetVersion(pollCtx)
+	}
+	var snapshot *models.ConnectionsSnapshot
+	if err == nil {
+		snapshot, err = client.GetConnectionsSnapshot(pollCtx)
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Warn("Core unreachable", "page", "overview", "profile", core.profile.Name, "err", err)
+	}
+
+	o.mutex.Lock()
+	core.checked = true
+	core.err = err
+	if err == nil {
+		core.config = cfg
+		if version != nil {
+			core.version = version.Version
+		}
+		core.connections = len(snapshot.Connections)
+		core.memory = snapshot.Memory
+	}
+	o.mutex.Unlock()
+
+	ui.Updater.UpdateUi(func() {
+		o.updateRow(index)
+		o.updateDetail()
+	})
+}
+
+// watchTraffic streams a core's transfer rate until the page is deactivated
+func (o *OverviewPage) watchTraffic(ctx context.Context, index int, core *coreStatus, client api.Controller) {
+	api.SuperviseUntracked(ctx, "overview traffic "+core.profile.Name, func(ctx context.Context, alive func()) error {
+		return client.StreamTraffic(ctx, func(traffic *models.Traffic) {
+			alive()
+			o.setTraffic(index, core, traffic)
+		})
+	}, func(error) {
+		o.setTraffic(index, core, nil)
+	})
+}
+
+// setTraffic stores the latest rate of a core and redraws its row
+func (o *OverviewPage) setTraffic(index int, core *coreStatus, traffic *models.Traffic) {
+	o.mutex.Lock()
+	core.traffic = traffic
+	o.mutex.Unlock()
+
+	ui.Updater.UpdateUi(func() {
+		o.updateRow(index)
+	})
+}
+
+// coreAt returns the core shown in a table row, or nil for the header
+func (o *OverviewPage) coreAt(row int) *coreStatus {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	if row < 1 || row > len(o.cores) {
+		return nil
+	}
+	return o.cores[row-1]
+}
+
+// updateTable rebuilds the table for the current set of cores
+func (o *OverviewPage) updateTable() {
+	o.coresTable.Clear()
+
+	headers := []string{"配置", "状态", "版本", "模式", "TUN", "上传", "下载", "连接数", "内存"}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false)
+		o.coresTable.SetCell(0, i, cell)
+	}
+
+	o.mutex.RLock()
+	count := len(o.cores)
+	o.mutex.RUnlock()
+
+	for i := 0; i < count; i++ {
+		o.updateRow(i)
+	}
+	if count > 0 {
+		o.coresTable.Select(1, 0)
+	}
+	o.updateDetail()
+}
+
+// updateRow redraws the row of a core
+func (o *OverviewPage) updateRow(index int) {
+	o.mutex.RLock()
+	if index >= len(o.cores) {
+		o.mutex.RUnlock()
+		return
+	}
+	core := *o.cores[index]
+	o.mutex.RUnlock()
+
+	name := core.profile.Name
+	if name == o.configManager.Get().Active().Name {
+		name = "● " + name
+	}
+
+	status := "[gray]◌ 检测中"
+	mode, tun, up, down, connections, memory := "-", "-", "-", "-", "-", "-"
+	switch {
+	case !core.checked:
+	case core.err != nil:
+		status = "[red]○ 离线"
+		var apiErr *api.APIError
+		if errors.As(core.err, &apiErr) {
+			status += fmt.Sprintf(" (%s)", apiErr.Kind)
+		}
+	default:
+		status = "[green]● 在线"
+		if core.config != nil {
+			mode = core.config.Mode
+			tun = "OFF"
+			if core.config.Tun.Enable {
+				tun = "ON"
+			}
+		}
+		connections = fmt.Sprintf("%d", core.connections)
+		if core.memory > 0 {
+			memory = utils.FormatBytes(core.memory)
+		}
+	}
+	if core.traffic != nil {
+		up = utils.FormatBytes(core.traffic.Up) + "/s"
+		down = utils.FormatBytes(core.traffic.Down) + "/s"
+	}
+
+	version := core.version
+	if version == "" {
+		version = "-"
+	}
+
+	row := index + 1
+	cells := []string{tview.Escape(name), status, tview.Escape(version), mode, tun, up, down, connections, memory}
+	for i, text := range cells {
+		cell := tview.NewTableCell(text).SetExpansion(1)
+		if i == 0 {
+			cell.SetTextColor(tcell.ColorWhite)
+		}
+		o.coresTable.SetCell(row, i, cell)
+	}
+}
+
+// updateDetail shows the address and last error of the selected core
+func (o *OverviewPage) updateDetail() {
+	row, _ := o.coresTable.GetSelection()
+	core := o.coreAt(row)
+	if core == nil {
+		o.detailText.SetText("[gray]没有配置[white]")
+		return
+	}
+
+	o.mutex.RLock()
+	profile := core.profile
+	err := core.err
+	o.mutex.RUnlock()
+
+	content := strings.Builder{}
+	content.WriteString(fmt.Sprintf("[yellow]%s[white]  %s\n", tview.Escape(profile.Name), tview.Escape(profile.API.BaseURL)))
+	if profile.API.SSH.Enabled() {
+		content.WriteString(fmt.Sprintf("[gray]经 SSH %s[white]\n", tview.Escape(profile.API.SSH.Host)))
+	}
+	if err != nil {
+		content.WriteString(fmt.Sprintf("[red]%s[white]\n", tview.Escape(describeError(err))))
+	}
+	content.WriteString("\n[gray]快捷键:[white] [yellow]Enter[white] 切换到此核心 [yellow]R[white] 刷新")
+
+	o.detailText.SetText(content.String())
+}
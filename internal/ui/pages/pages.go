@@ -1,9 +1,9 @@
 package pages
 
 import (
+	"mihomoTui/internal/api"
 	"mihomoTui/internal/config"
-
-	"github.com/rivo/tview"
+	"mihomoTui/internal/ui"
 )
 
 // ActivatablePage interface for pages that need activation/deactivation control
@@ -13,49 +13,82 @@ type ActivatablePage interface {
 }
 
 // NewDashboard creates a new dashboard page
-func NewDashboard() *Dashboard {
+func NewDashboard(controller api.Controller, store *ui.Store) *Dashboard {
 	return &Dashboard{
-		DashboardPage: NewDashboardPage(),
+		DashboardPage: NewDashboardPage(controller, store),
 	}
 }
 
 // NewProxies creates a new proxies page
-func NewProxies() *Proxies {
+func NewProxies(controller api.Controller, store *ui.Store) *Proxies {
 	return &Proxies{
-		ProxiesPage: NewProxiesPage(),
+		ProxiesPage: NewProxiesPage(controller, store),
 	}
 }
 
 // NewConnections creates a new connections page
-func NewConnections() *Connections {
+func NewConnections(controller api.Controller, store *ui.Store) *Connections {
 	return &Connections{
-		ConnectionsPage: NewConnectionsPage(),
+		ConnectionsPage: NewConnectionsPage(controller, store),
 	}
 }
 
-// NewConfig creates a new config page
-func NewConfig(configManager *config.Manager) *Config {
+// NewConfig creates a new config page. onProfileChange is called whenever
+// the active profile changes or is edited.
+func NewConfig(configManager *config.Manager, onProfileChange func()) *Config {
 	return &Config{
-		ConfigPage: NewConfigPage(configManager),
+		ConfigPage: NewConfigPage(configManager, onProfileChange),
 	}
 }
 
 // NewLogs creates a new logs page
-func NewLogs() *Logs {
+func NewLogs(controller api.Controller) *Logs {
 	return &Logs{
-		LogsPage: NewLogsPage(),
+		LogsPage: NewLogsPage(controller),
+	}
+}
+
+// NewRules creates a new rules page
+func NewRules(controller api.Controller) *Rules {
+	return &Rules{
+		RulesPage: NewRulesPage(controller),
 	}
 }
 
-// NewSettings creates a new settings page
-func NewSettings(configManager *config.Manager) *Settings {
-	settings := &Settings{
-		TextView:      tview.NewTextView(),
-		configManager: configManager,
+// NewRuleProviders creates a new rule providers page
+func NewRuleProviders(controller api.Controller) *RuleProviders {
+	return &RuleProviders{
+		RuleProvidersPage: NewRuleProvidersPage(controller),
 	}
-	settings.SetBorder(true)
-	settings.SetTitle(" 设置 ")
-	settings.SetText("设置页面 - 开发中...")
+}
+
+// NewProviders creates a new proxy providers page
+func NewProviders(controller api.Controller) *Providers {
+	return &Providers{
+		ProvidersPage: NewProvidersPage(controller),
+	}
+}
 
-	return settings
+// NewDNS creates a new DNS query page
+func NewDNS(controller api.Controller) *DNS {
+	return &DNS{
+		DNSPage: NewDNSPage(controller),
+	}
+}
+
+// NewOverview creates a new multi-core overview page. onSelect is called
+// with the profile name when a core is opened.
+func NewOverview(configManager *config.Manager, onSelect func(name string)) *Overview {
+	return &Overview{
+		OverviewPage: NewOverviewPage(configManager, onSelect),
+	}
+}
+
+// NewSettings creates a new settings page. onApply is called after the
+// appearance settings were saved, onRunWizard when the user asks for the
+// setup wizard.
+func NewSettings(configManager *config.Manager, onApply, onRunWizard func()) *Settings {
+	return &Settings{
+		SettingsPage: NewSettingsPage(configManager, onApply, onRunWizard),
+	}
 }
@@ -0,0 +1,505 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+	"mihomoTui/internal/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// quotaBarWidth is the number of cells used by the subscription quota bar
+const quotaBarWidth = 10
+
+// Providers represents the proxy providers page
+type Providers struct {
+	*ProvidersPage
+}
+
+// Activate activates the providers page
+func (p *Providers) Activate() {
+	slog.Debug("Activating page", "page", "providers")
+	p.ProvidersPage.Activate()
+}
+
+// Deactivate deactivates the providers page
+func (p *Providers) Deactivate() {
+	slog.Debug("Deactivating page", "page", "providers")
+	p.ProvidersPage.Deactivate()
+}
+
+// ProvidersPage represents the proxy provider (subscription) page
+type ProvidersPage struct {
+	*tview.Flex
+	controller api.Controller
+
+	// Components
+	providersTable *tview.Table
+	detailPanel    *tview.TextView
+	statusText     *tview.TextView
+
+	// Data
+	providers    []*models.ProxyProvider
+	selectedName string
+
+	// Control
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.RWMutex
+
+	// State
+	isActive    bool
+	isBusy      bool
+	lastUpdate  time.Time
+	unsupported string // Set when the core lacks the providers API
+}
+
+// NewProvidersPage creates a new proxy providers page
+func NewProvidersPage(controller api.Controller) *ProvidersPage {
+	page := &ProvidersPage{
+		Flex:       tview.NewFlex(),
+		controller: controller,
+	}
+
+	page.setupLayout()
+	page.setupEventHandlers()
+
+	return page
+}
+
+// Activate loads the providers when the page becomes active
+func (p *ProvidersPage) Activate() {
+	p.mutex.Lock()
+	p.isActive = true
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.mutex.Unlock()
+
+	p.loadProvidersData()
+
+	go ui.Updater.UpdateUi(func() {
+		p.updateProvidersTable()
+	})
+}
+
+// Deactivate unloads the providers
+func (p *ProvidersPage) Deactivate() {
+	p.mutex.Lock()
+	p.isActive = false
+	p.providers = nil
+	p.selectedName = ""
+	p.mutex.Unlock()
+
+	// Abort loads, updates and health checks still in flight
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// setupLayout sets up the providers page layout
+func (p *ProvidersPage) setupLayout() {
+	p.createProvidersTable()
+	p.createDetailPanel()
+	p.createStatusText()
+
+	// Right panel (details + status)
+	rightPanel := tview.NewFlex().SetDirection(tview.FlexRow)
+	rightPanel.AddItem(p.detailPanel, 0, 1, false)
+	rightPanel.AddItem(p.statusText, 7, 0, false)
+
+	p.SetDirection(tview.FlexColumn)
+	p.AddItem(p.providersTable, 0, 3, true)
+	p.AddItem(rightPanel, 40, 0, false)
+
+	p.SetBorder(true)
+	p.SetTitle(" 订阅 ")
+}
+
+// createProvidersTable creates the providers table
+func (p *ProvidersPage) createProvidersTable() {
+	p.providersTable = tview.NewTable().SetFixed(1, 0)
+	p.providersTable.SetBorder(true)
+	p.providersTable.SetTitle(" 代理提供者 ")
+	p.providersTable.SetSelectable(true, false)
+	p.setTableHeaders()
+}
+
+// setTableHeaders sets the providers table headers
+func (p *ProvidersPage) setTableHeaders() {
+	headers := []string{"名称", "来源", "节点数", "更新时间", "已用流量", "到期时间"}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false)
+		p.providersTable.SetCell(0, i, cell)
+	}
+}
+
+// createDetailPanel creates the provider detail panel
+func (p *ProvidersPage) createDetailPanel() {
+	p.detailPanel = tview.NewTextView()
+	p.detailPanel.SetBorder(true)
+	p.detailPanel.SetTitle(" 订阅详情 ")
+	p.detailPanel.SetDynamicColors(true)
+	p.detailPanel.SetWordWrap(true)
+	p.detailPanel.SetText("选择一个订阅查看详细信息")
+}
+
+// createStatusText creates the status display
+func (p *ProvidersPage) createStatusText() {
+	p.statusText = tview.NewTextView()
+	p.statusText.SetBorder(true)
+	p.statusText.SetTitle(" 状态 ")
+	p.statusText.SetDynamicColors(true)
+	p.statusText.SetText("加载中...")
+}
+
+// setupEventHandlers sets up event handlers
+func (p *ProvidersPage) setupEventHandlers() {
+	p.providersTable.SetSelectionChangedFunc(func(row, column int) {
+		if cell := p.providersTable.GetCell(row, 0); row > 0 && cell != nil {
+			if name, ok := cell.GetReference().(string); ok {
+				p.selectedName = name
+				p.updateDetailPanel()
+			}
+		}
+	})
+
+	p.providersTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			p.updateSelectedProvider()
+			return nil
+		case tcell.KeyCtrlR:
+			p.Refresh()
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'u', 'U':
+			p.updateSelectedProvider()
+			return nil
+		case 'h', 'H':
+			p.healthCheckSelectedProvider()
+			return nil
+		case 'r', 'R':
+			p.Refresh()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// loadProvidersData loads subscription providers from /providers/proxies API
+func (p *ProvidersPage) loadProvidersData() {
+	p.mutex.RLock()
+	isActive := p.isActive
+	p.mutex.RUnlock()
+
+	if !isActive {
+		return
+	}
+
+	caps := p.controller.Capabilities(p.ctx)
+	supported := caps.Has(api.FeatureProxyProviders)
+	p.mutex.Lock()
+	p.unsupported = ""
+	if !supported {
+		p.unsupported = describeUnsupported(caps, api.FeatureProxyProviders)
+	}
+	p.mutex.Unlock()
+	if !supported {
+		return
+	}
+
+	result, err := p.controller.GetProviders(p.ctx)
+	if err != nil {
+		p.showError(fmt.Sprintf("获取订阅失败: %s", describeError(err)))
+		return
+	}
+
+	// Proxy groups are reported as "Compatible" providers; only keep real ones
+	providers := make([]*models.ProxyProvider, 0, len(result.Providers))
+	for _, provider := range result.Providers {
+		if provider != nil && provider.VehicleType != "Compatible" {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+
+	p.mutex.Lock()
+	if p.isActive {
+		p.providers = providers
+		p.lastUpdate = time.Now()
+	}
+	p.mutex.Unlock()
+}
+
+// updateProvidersTable redraws the providers table
+func (p *ProvidersPage) updateProvidersTable() {
+	p.mutex.RLock()
+	providers := p.providers
+	selectedName := p.selectedName
+	p.mutex.RUnlock()
+
+	p.providersTable.Clear()
+	p.setTableHeaders()
+
+	selectedRow := 1
+	for i, provider := range providers {
+		row := i + 1
+		expireText, expireColor := formatExpire(provider.SubscriptionInfo)
+		// Names from the core may contain [...], which tview reads as tags
+		cells := []struct {
+			text  string
+			color tcell.Color
+			align int
+		}{
+			{tview.Escape(provider.Name), tcell.ColorWhite, tview.AlignLeft},
+			{tview.Escape(provider.VehicleType), tcell.ColorPurple, tview.AlignCenter},
+			{fmt.Sprintf("%d", len(provider.Proxies)), tcell.ColorGreen, tview.AlignRight},
+			{formatAge(provider.UpdatedAt), ageColor(provider.UpdatedAt), tview.AlignRight},
+			{formatQuota(provider.SubscriptionInfo), tcell.ColorWhite, tview.AlignLeft},
+			{expireText, expireColor, tview.AlignLeft},
+		}
+
+		for col, cellData := range cells {
+			cell := tview.NewTableCell(cellData.text).
+				SetTextColor(cellData.color).
+				SetAlign(cellData.align)
+			cell.SetReference(provider.Name)
+			p.providersTable.SetCell(row, col, cell)
+		}
+
+		if provider.Name == selectedName {
+			selectedRow = row
+		}
+	}
+
+	if len(providers) > 0 {
+		p.providersTable.Select(selectedRow, 0)
+	} else {
+		p.detailPanel.SetText("没有订阅类型的代理提供者")
+	}
+	p.updateStatus("")
+}
+
+// updateDetailPanel shows details of the selected provider
+func (p *ProvidersPage) updateDetailPanel() {
+	provider := p.findProvider(p.selectedName)
+	if provider == nil {
+		return
+	}
+
+	alive, tested := 0, 0
+	for _, proxy := range provider.Proxies {
+		if proxy == nil || len(proxy.History) == 0 {
+			continue
+		}
+		tested++
+		if proxy.History[len(proxy.History)-1].Delay > 0 {
+			alive++
+		}
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "[yellow]名称:[white] %s\n", tview.Escape(provider.Name))
+	fmt.Fprintf(&content, "[yellow]类型:[white] %s (%s)\n", tview.Escape(provider.Type), tview.Escape(provider.VehicleType))
+	fmt.Fprintf(&content, "[yellow]节点:[white] %d (可用 %d / 已测 %d)\n", len(provider.Proxies), alive, tested)
+	if !provider.UpdatedAt.IsZero() {
+		fmt.Fprintf(&content, "[yellow]更新:[white] %s (%s)\n",
+			provider.UpdatedAt.Local().Format("2006-01-02 15:04:05"), formatAge(provider.UpdatedAt))
+	}
+	if provider.TestUrl != "" {
+		fmt.Fprintf(&content, "[yellow]测试地址:[white] %s\n", tview.Escape(provider.TestUrl))
+	}
+
+	if info := provider.SubscriptionInfo; info != nil {
+		expireText, _ := formatExpire(info)
+		fmt.Fprintf(&content, "\n[yellow]上传:[white] %s\n", utils.FormatBytes(info.Upload))
+		fmt.Fprintf(&content, "[yellow]下载:[white] %s\n", utils.FormatBytes(info.Download))
+		if info.Total > 0 {
+			fmt.Fprintf(&content, "[yellow]总量:[white] %s (剩余 %s)\n",
+				utils.FormatBytes(info.Total), utils.FormatBytes(max(info.Total-info.Upload-info.Download, 0)))
+		}
+		fmt.Fprintf(&content, "[yellow]到期:[white] %s\n", expireText)
+	} else {
+		content.WriteString("\n[gray]该订阅未提供流量信息[white]\n")
+	}
+
+	p.detailPanel.SetText(content.String())
+}
+
+// findProvider returns the provider with the given name
+func (p *ProvidersPage) findProvider(name string) *models.ProxyProvider {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for _, provider := range p.providers {
+		if provider.Name == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// updateStatus shows the summary line, optionally followed by a message
+func (p *ProvidersPage) updateStatus(message string) {
+	p.mutex.RLock()
+	count := len(p.providers)
+	nodes := 0
+	for _, provider := range p.providers {
+		nodes += len(provider.Proxies)
+	}
+	lastUpdate := p.lastUpdate
+	unsupported := p.unsupported
+	p.mutex.RUnlock()
+
+	if unsupported != "" {
+		p.statusText.SetText(fmt.Sprintf("[yellow]%s[white]", unsupported))
+		return
+	}
+
+	if message == "" {
+		message = "[gray]快捷键:[white]\n[yellow]Enter/U[white] 更新订阅 [yellow]H[white] 健康检查\n[yellow]R[white] 刷新"
+	}
+
+	p.statusText.SetText(fmt.Sprintf("[green]● %d[white] 个订阅 | %d 个节点 | [yellow]%s[white]\n%s",
+		count, nodes, lastUpdate.Format("15:04"), message))
+}
+
+// updateSelectedProvider pulls the selected subscription again
+func (p *ProvidersPage) updateSelectedProvider() {
+	name := p.selectedName
+	p.runAction(name, "更新", func() error {
+		return p.controller.UpdateProxyProvider(p.ctx, name)
+	})
+}
+
+// healthCheckSelectedProvider tests every node of the selected provider
+func (p *ProvidersPage) healthCheckSelectedProvider() {
+	name := p.selectedName
+	p.runAction(name, "健康检查", func() error {
+		return p.controller.HealthCheckProxyProvider(p.ctx, name)
+	})
+}
+
+// runAction runs a provider action in the background and reloads afterwards
+func (p *ProvidersPage) runAction(name, label string, action func() error) {
+	p.mutex.Lock()
+	if name == "" || p.isBusy {
+		p.mutex.Unlock()
+		return
+	}
+	p.isBusy = true
+	p.mutex.Unlock()
+
+	p.updateStatus(fmt.Sprintf("[yellow]正在%s: %s...[white]", label, tview.Escape(name)))
+
+	go func() {
+		defer func() {
+			p.mutex.Lock()
+			p.isBusy = false
+			p.mutex.Unlock()
+		}()
+
+		if err := action(); err != nil {
+			slog.Warn("Provider action failed", "page", "providers", "provider", name, "action", label, "err", err)
+			ui.Updater.UpdateUi(func() {
+				p.updateStatus(fmt.Sprintf("[red]%s失败: %s[white]", label, tview.Escape(describeError(err))))
+			})
+			return
+		}
+
+		p.loadProvidersData()
+		ui.Updater.UpdateUi(func() {
+			p.updateProvidersTable()
+			p.updateDetailPanel()
+			p.updateStatus(fmt.Sprintf("[green]%s完成: %s[white]", label, tview.Escape(name)))
+		})
+	}()
+}
+
+// Refresh reloads the providers from the API
+func (p *ProvidersPage) Refresh() {
+	p.updateStatus("[yellow]正在刷新订阅...[white]")
+
+	go func() {
+		p.loadProvidersData()
+		ui.Updater.UpdateUi(func() {
+			p.updateProvidersTable()
+		})
+	}()
+}
+
+// showError shows an error message
+func (p *ProvidersPage) showError(message string) {
+	slog.Warn(message, "page", "providers")
+	go ui.Updater.UpdateUi(func() {
+		p.statusText.SetText(fmt.Sprintf("[red]错误:[white] %s", message))
+	})
+}
+
+// formatQuota renders used/total traffic as a bar
+func formatQuota(info *models.SubscriptionInfo) string {
+	if info == nil {
+		return "-"
+	}
+
+	used := info.Upload + info.Download
+	if info.Total <= 0 {
+		return utils.FormatBytes(used)
+	}
+
+	ratio := float64(used) / float64(info.Total)
+	filled := min(int(ratio*quotaBarWidth+0.5), quotaBarWidth)
+
+	color := "green"
+	switch {
+	case ratio >= 0.9:
+		color = "red"
+	case ratio >= 0.7:
+		color = "yellow"
+	}
+
+	return fmt.Sprintf("[%s]%s[gray]%s[white] %s / %s",
+		color, strings.Repeat("█", filled), strings.Repeat("░", quotaBarWidth-filled),
+		utils.FormatBytes(used), utils.FormatBytes(info.Total))
+}
+
+// formatExpire renders the subscription expiry date and days left
+func formatExpire(info *models.SubscriptionInfo) (string, tcell.Color) {
+	if info == nil {
+		return "-", tcell.ColorGray
+	}
+	if info.Expire <= 0 {
+		return "长期有效", tcell.ColorGreen
+	}
+
+	expire := time.Unix(info.Expire, 0)
+	days := int(time.Until(expire).Hours() / 24)
+	date := expire.Format("2006-01-02")
+
+	switch {
+	case days < 0:
+		return fmt.Sprintf("%s (已过期)", date), tcell.ColorRed
+	case days < 7:
+		return fmt.Sprintf("%s (%d 天)", date, days), tcell.ColorRed
+	case days < 30:
+		return fmt.Sprintf("%s (%d 天)", date, days), tcell.ColorYellow
+	default:
+		return fmt.Sprintf("%s (%d 天)", date, days), tcell.ColorGreen
+	}
+}
@@ -3,7 +3,7 @@ package pages
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"mihomoTui/internal/api"
 	"mihomoTui/internal/models"
 	"mihomoTui/internal/ui"
@@ -23,19 +23,21 @@ type Proxies struct {
 
 // Activate activates the proxies page
 func (p *Proxies) Activate() {
-	log.Printf("Activating proxies page")
+	slog.Debug("Activating page", "page", "proxies")
 	p.ProxiesPage.Activate()
 }
 
 // Deactivate deactivates the proxies page
 func (p *Proxies) Deactivate() {
-	log.Printf("Deactivating proxies page")
+	slog.Debug("Deactivating page", "page", "proxies")
 	p.ProxiesPage.Deactivate()
 }
 
 // ProxiesPage represents the proxies management page
 type ProxiesPage struct {
 	*tview.Flex
+	controller api.Controller
+	store      *ui.Store
 
 	// Components
 	groupsList    *tview.List
@@ -56,13 +58,16 @@ type ProxiesPage struct {
 	currentMode   string
 
 	// Control
-	cancel context.CancelFunc
-	mutex  sync.RWMutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mutex       sync.RWMutex
+	unsubscribe []func() // Store subscriptions while active
 
 	// State
 	isActive       bool
 	isTestingDelay bool
 	lastUpdate     time.Time
+	rebuildGroups  bool // The next proxies update rebuilds the groups list
 
 	// Navigation
 	focusableComponents []tview.Primitive
@@ -72,9 +77,11 @@ type ProxiesPage struct {
 }
 
 // NewProxiesPage creates a new proxies management page
-func NewProxiesPage() *ProxiesPage {
+func NewProxiesPage(controller api.Controller, store *ui.Store) *ProxiesPage {
 	page := &ProxiesPage{
 		Flex:          tview.NewFlex(),
+		controller:    controller,
+		store:         store,
 		providersData: make(map[string]*models.ProxyProvider),
 		currentMode:   "rule", // Default mode
 	}
@@ -87,16 +94,56 @@ func NewProxiesPage() *ProxiesPage {
 
 // Activate initializes the page when it becomes active
 func (p *ProxiesPage) Activate() {
-	// Start data loading
-	p.loadProvidersData()
+	// Requests and delay tests started while active are cancelled on Deactivate
+	p.mutex.Lock()
+	p.isActive = true
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.rebuildGroups = true
+	p.mutex.Unlock()
 
-	go ui.Updater.UpdateUi(func() {
-		p.updateGroupsList()
-		p.statusText.SetText("加载完成")
-	})
+	unsubscribe := []func(){
+		p.store.Proxies.Subscribe(p.onProxiesUpdate, func(err error) {
+			p.showError(fmt.Sprintf("获取代理数据失败: %s", describeError(err)))
+		}),
+		// The mode buttons follow the core, whichever page changed it
+		p.store.Config.Subscribe(func(config *models.Config) {
+			mode := strings.ToLower(config.Mode)
+			ui.Updater.UpdateUi(func() {
+				p.currentMode = mode
+				p.updateButtonStyles()
+			})
+		}, nil),
+	}
+
+	p.mutex.Lock()
+	p.unsubscribe = unsubscribe
+	p.mutex.Unlock()
+}
+
+// onProxiesUpdate shows proxies from the store. The groups list is only
+// rebuilt after activation or a manual refresh, so the selection survives
+// updates caused by switching nodes or testing delays.
+func (p *ProxiesPage) onProxiesUpdate(providers map[string]*models.ProxyProvider) {
+	p.mutex.Lock()
+	if !p.isActive {
+		p.mutex.Unlock()
+		return
+	}
+	p.providersData = providers
+	p.lastUpdate = time.Now()
+	rebuild := p.rebuildGroups
+	p.rebuildGroups = false
+	p.mutex.Unlock()
 
-	// Sync mode state from StatusBar
-	go p.syncModeFromStatusBar()
+	ui.Updater.UpdateUi(func() {
+		if rebuild {
+			p.updateGroupsList()
+			p.statusText.SetText("加载完成")
+			return
+		}
+		p.updateNodesListContent(false)
+		p.updateCurrentSelectionUI()
+	})
 }
 
 // Deactivate deactivates the proxies page and unloads data
@@ -108,8 +155,14 @@ func (p *ProxiesPage) Deactivate() {
 	p.groups = nil
 	p.selectedGroup = ""
 	p.selectedNode = ""
+	unsubscribe := p.unsubscribe
+	p.unsubscribe = nil
 	p.mutex.Unlock()
 
+	for _, fn := range unsubscribe {
+		fn()
+	}
+
 	// Cancel any ongoing operations
 	if p.cancel != nil {
 		p.cancel()
@@ -225,28 +278,6 @@ func (p *ProxiesPage) updateButtonStyles() {
 	}
 }
 
-// syncModeFromStatusBar synchronizes mode state from StatusBar
-func (p *ProxiesPage) syncModeFromStatusBar() {
-	currentMode := ui.Updater.GetCurrentMode()
-	if currentMode != "" && currentMode != "Unknown" {
-		// Convert mode to lowercase to match our internal format
-		switch currentMode {
-		case "Rule":
-			p.currentMode = "rule"
-		case "Global":
-			p.currentMode = "global"
-		case "Direct":
-			p.currentMode = "direct"
-		default:
-			p.currentMode = strings.ToLower(currentMode)
-		}
-		// Update button highlights
-		ui.Updater.UpdateUi(func() {
-			p.updateButtonStyles()
-		})
-	}
-}
-
 // createNodesList creates the proxy nodes table
 func (p *ProxiesPage) createNodesList() {
 	p.nodesList = tview.NewTable().SetFixed(1, 0)
@@ -332,20 +363,6 @@ func (p *ProxiesPage) setupEventHandlers() {
 	})
 }
 
-// loadProvidersData loads data from /providers/proxies API
-func (p *ProxiesPage) loadProvidersData() {
-	providers, err := api.Client.GetProviders()
-	if err != nil {
-		p.showError(fmt.Sprintf("获取代理数据失败: %v", err))
-		return
-	}
-
-	p.mutex.Lock()
-	p.providersData = providers.Providers
-	p.lastUpdate = time.Now()
-	p.mutex.Unlock()
-}
-
 // updateGroupsList updates the groups list
 func (p *ProxiesPage) updateGroupsList() {
 	p.mutex.RLock()
@@ -594,34 +611,16 @@ func (p *ProxiesPage) switchMode(mode string) {
 	p.showInfo(fmt.Sprintf("正在切换到 %s 模式...", mode))
 
 	go func() {
-		// Get current config
-		config, err := api.Client.GetConfig()
-		if err != nil {
-			p.showError(fmt.Sprintf("获取配置失败: %v", err))
+		if err := p.controller.SetMode(p.ctx, mode); err != nil {
+			p.showError(fmt.Sprintf("切换模式失败: %s", describeError(err)))
 			return
 		}
 
-		// Update mode
-		config.Mode = mode
-		err = api.Client.UpdateConfig(config)
-		if err != nil {
-			p.showError(fmt.Sprintf("切换模式失败: %v", err))
-			return
-		}
-
-		// Update local state
-		p.currentMode = mode
-
-		// Update StatusBar with new config
-		ui.Updater.UpdateStatusBarConfig(config)
-
-		// Update button colors
-		go ui.Updater.UpdateUi(func() {
-			p.updateButtonStyles()
-		})
+		// Every page showing the mode picks it up from the store
+		p.store.Config.Refresh()
 
 		p.showSuccess(fmt.Sprintf("已切换到 %s 模式", mode))
-		log.Printf("Switched to mode: %s", mode)
+		slog.Info("Switched mode", "page", "proxies", "mode", mode)
 	}()
 }
 
@@ -632,30 +631,16 @@ func (p *ProxiesPage) selectCurrentNode() {
 	}
 
 	go func() {
-		err := api.Client.SelectProxy(p.selectedGroup, p.selectedNode)
+		err := p.controller.SelectProxy(p.ctx, p.selectedGroup, p.selectedNode)
 		if err != nil {
-			p.showError(fmt.Sprintf("切换代理失败: %v", err))
+			p.showError(fmt.Sprintf("切换代理失败: %s", describeError(err)))
 			return
 		}
 
 		p.showSuccess(fmt.Sprintf("已切换到代理: %s", p.selectedNode))
 
-		// Update current selection in data structure directly
-		p.mutex.Lock()
-		if defaultProvider, exists := p.providersData["default"]; exists {
-			for _, proxy := range defaultProvider.Proxies {
-				if proxy != nil && proxy.Name == p.selectedGroup {
-					proxy.Now = p.selectedNode
-					break
-				}
-			}
-		}
-		p.mutex.Unlock()
-
-		// Update UI to reflect the change directly
-		go ui.Updater.UpdateUi(func() {
-			p.updateCurrentSelectionUI()
-		})
+		// Reload so the selection shows what the core applied
+		p.store.Proxies.Refresh()
 	}()
 }
 
@@ -666,30 +651,29 @@ func (p *ProxiesPage) testGroupDelay() {
 	}
 
 	p.isTestingDelay = true
-	p.showInfo("正在测试组内所有节点延迟...")
 
 	go func() {
 		defer func() {
 			p.isTestingDelay = false
 		}()
 
+		if caps := p.controller.Capabilities(p.ctx); !caps.Has(api.FeatureGroupDelay) {
+			p.showInfo(describeUnsupported(caps, api.FeatureGroupDelay) + "，请用 R 逐个测试节点")
+			return
+		}
+		p.showInfo("正在测试组内所有节点延迟...")
+
 		// TODO: Use the new API endpoint
-		err := api.Client.TestGroupDelay(p.selectedGroup, "http://www.gstatic.com/generate_204", 3000)
+		err := p.controller.TestGroupDelay(p.ctx, p.selectedGroup, "http://www.gstatic.com/generate_204", 3000)
 		if err != nil {
-			p.showError(fmt.Sprintf("组延迟测试失败: %v", err))
+			p.showError(fmt.Sprintf("组延迟测试失败: %s", describeError(err)))
 			return
 		}
 
 		p.showSuccess("组延迟测试完成")
 
-		// The group delay test API should update all node delays
-		// We need to refresh data once to get the updated delays from the API
-		time.Sleep(1 * time.Second)
-		p.loadProvidersData()
-
-		go ui.Updater.UpdateUi(func() {
-			p.updateNodesListContent(false) // Update without rebuilding
-		})
+		// The core records the delays in each node's history
+		p.store.Proxies.Refresh()
 	}()
 }
 
@@ -707,40 +691,17 @@ func (p *ProxiesPage) testSelectedNodeDelay() {
 			p.isTestingDelay = false
 		}()
 
-		delay, err := api.Client.TestProxyDelay(p.selectedNode, "http://www.gstatic.com/generate_204", 5000)
+		delay, err := p.controller.TestProxyDelay(p.ctx, p.selectedNode, "http://www.gstatic.com/generate_204", 5000)
 		if err != nil {
-			p.showError(fmt.Sprintf("延迟测试失败: %v", err))
+			p.showError(fmt.Sprintf("延迟测试失败: %s", describeError(err)))
 			return
 		}
 
 		if delay > 0 {
 			p.showSuccess(fmt.Sprintf("%s 延迟: %dms", p.selectedNode, delay))
 
-			// Update delay in data structure directly
-			p.mutex.Lock()
-			if provider, exists := p.providersData[p.selectedGroup]; exists {
-				for _, proxy := range provider.Proxies {
-					if proxy != nil && proxy.Name == p.selectedNode {
-						// Add new delay to history
-						newHistory := models.ProxyHistory{
-							Time:  time.Now(),
-							Delay: delay,
-						}
-						proxy.History = append(proxy.History, newHistory)
-						// Keep only last 10 history entries
-						if len(proxy.History) > 10 {
-							proxy.History = proxy.History[len(proxy.History)-10:]
-						}
-						break
-					}
-				}
-			}
-			p.mutex.Unlock()
-
-			// Update only the nodes list UI (without rebuilding)
-			go ui.Updater.UpdateUi(func() {
-				p.updateNodesListContent(false)
-			})
+			// The core records the delay in the node's history
+			p.store.Proxies.Refresh()
 		} else {
 			p.showError(fmt.Sprintf("%s 延迟测试超时", p.selectedNode))
 		}
@@ -754,11 +715,10 @@ func (p *ProxiesPage) Refresh() {
 	}
 
 	p.showInfo("正在刷新代理数据...")
-	p.loadProvidersData()
-
-	go ui.Updater.UpdateUi(func() {
-		p.updateGroupsList()
-	})
+	p.mutex.Lock()
+	p.rebuildGroups = true
+	p.mutex.Unlock()
+	p.store.Proxies.Refresh()
 }
 
 // Stop stops all background processes
@@ -770,7 +730,7 @@ func (p *ProxiesPage) Stop() {
 
 // showError shows an error message
 func (p *ProxiesPage) showError(message string) {
-	log.Printf("Error: %s", message)
+	slog.Warn(message, "page", "proxies")
 	if p.statusText != nil {
 		go ui.Updater.UpdateUi(func() {
 			p.statusText.SetText(fmt.Sprintf("[red]错误:[white] %s", message))
@@ -780,7 +740,7 @@ func (p *ProxiesPage) showError(message string) {
 
 // showSuccess shows a success message
 func (p *ProxiesPage) showSuccess(message string) {
-	log.Printf("Success: %s", message)
+	slog.Info(message, "page", "proxies")
 	if p.statusText != nil {
 		go ui.Updater.UpdateUi(func() {
 			p.statusText.SetText(fmt.Sprintf("[green]成功:[white] %s", message))
@@ -790,7 +750,7 @@ func (p *ProxiesPage) showSuccess(message string) {
 
 // showInfo shows an info message
 func (p *ProxiesPage) showInfo(message string) {
-	log.Printf("Info: %s", message)
+	slog.Debug(message, "page", "proxies")
 	if p.statusText != nil {
 		go ui.Updater.UpdateUi(func() {
 			p.statusText.SetText(fmt.Sprintf("[yellow]信息:[white] %s", message))
@@ -0,0 +1,449 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// RuleProviders represents the rule providers page
+type RuleProviders struct {
+	*RuleProvidersPage
+}
+
+// Activate activates the rule providers page
+func (r *RuleProviders) Activate() {
+	slog.Debug("Activating page", "page", "ruleproviders")
+	r.RuleProvidersPage.Activate()
+}
+
+// Deactivate deactivates the rule providers page
+func (r *RuleProviders) Deactivate() {
+	slog.Debug("Deactivating page", "page", "ruleproviders")
+	r.RuleProvidersPage.Deactivate()
+}
+
+// RuleProvidersPage represents the rule provider management page
+type RuleProvidersPage struct {
+	*tview.Flex
+	controller api.Controller
+
+	// Components
+	providersTable *tview.Table
+	statusText     *tview.TextView
+
+	// Data
+	providers     []*models.RuleProvider
+	updateResults map[string]string // Per-provider result of the last update
+	selectedName  string
+
+	// Control
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.RWMutex
+
+	// State
+	isActive    bool
+	isUpdating  bool
+	lastUpdate  time.Time
+	unsupported string // Set when the core lacks the providers API
+}
+
+// NewRuleProvidersPage creates a new rule providers page
+func NewRuleProvidersPage(controller api.Controller) *RuleProvidersPage {
+	page := &RuleProvidersPage{
+		Flex:          tview.NewFlex(),
+		controller:    controller,
+		updateResults: make(map[string]string),
+	}
+
+	page.setupLayout()
+	page.setupEventHandlers()
+
+	return page
+}
+
+// Activate loads the providers when the page becomes active
+func (r *RuleProvidersPage) Activate() {
+	r.mutex.Lock()
+	r.isActive = true
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.mutex.Unlock()
+
+	r.loadProvidersData()
+
+	go ui.Updater.UpdateUi(func() {
+		r.updateProvidersTable()
+	})
+}
+
+// Deactivate unloads the providers
+func (r *RuleProvidersPage) Deactivate() {
+	r.mutex.Lock()
+	r.isActive = false
+	r.providers = nil
+	r.updateResults = make(map[string]string)
+	r.selectedName = ""
+	r.mutex.Unlock()
+
+	// Abort loads and updates still in flight
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// setupLayout sets up the rule providers page layout
+func (r *RuleProvidersPage) setupLayout() {
+	r.createProvidersTable()
+	r.createStatusText()
+
+	r.SetDirection(tview.FlexRow)
+	r.AddItem(r.providersTable, 0, 1, true)
+	r.AddItem(r.statusText, 5, 0, false)
+
+	r.SetBorder(true)
+	r.SetTitle(" 规则集 ")
+}
+
+// createProvidersTable creates the providers table
+func (r *RuleProvidersPage) createProvidersTable() {
+	r.providersTable = tview.NewTable().SetFixed(1, 0)
+	r.providersTable.SetBorder(true)
+	r.providersTable.SetTitle(" 规则提供者 ")
+	r.providersTable.SetSelectable(true, false)
+	r.setTableHeaders()
+}
+
+// setTableHeaders sets the providers table headers
+func (r *RuleProvidersPage) setTableHeaders() {
+	headers := []string{"名称", "行为", "格式", "来源", "规则数", "更新时间", "状态"}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false)
+		r.providersTable.SetCell(0, i, cell)
+	}
+}
+
+// createStatusText creates the status display
+func (r *RuleProvidersPage) createStatusText() {
+	r.statusText = tview.NewTextView()
+	r.statusText.SetBorder(true)
+	r.statusText.SetTitle(" 状态 ")
+	r.statusText.SetDynamicColors(true)
+	r.statusText.SetText("加载中...")
+}
+
+// setupEventHandlers sets up event handlers
+func (r *RuleProvidersPage) setupEventHandlers() {
+	r.providersTable.SetSelectionChangedFunc(func(row, column int) {
+		if cell := r.providersTable.GetCell(row, 0); row > 0 && cell != nil {
+			if name, ok := cell.GetReference().(string); ok {
+				r.selectedName = name
+			}
+		}
+	})
+
+	r.providersTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			r.updateSelectedProvider()
+			return nil
+		case tcell.KeyCtrlR:
+			r.Refresh()
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'u', 'U':
+			r.updateSelectedProvider()
+			return nil
+		case 'a', 'A':
+			r.updateAllProviders()
+			return nil
+		case 'r', 'R':
+			r.Refresh()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// loadProvidersData loads data from /providers/rules API
+func (r *RuleProvidersPage) loadProvidersData() {
+	r.mutex.RLock()
+	isActive := r.isActive
+	r.mutex.RUnlock()
+
+	if !isActive {
+		return
+	}
+
+	caps := r.controller.Capabilities(r.ctx)
+	supported := caps.Has(api.FeatureRuleProviders)
+	r.mutex.Lock()
+	r.unsupported = ""
+	if !supported {
+		r.unsupported = describeUnsupported(caps, api.FeatureRuleProviders)
+	}
+	r.mutex.Unlock()
+	if !supported {
+		return
+	}
+
+	result, err := r.controller.GetRuleProviders(r.ctx)
+	if err != nil {
+		r.showError(fmt.Sprintf("获取规则集失败: %s", describeError(err)))
+		return
+	}
+
+	providers := make([]*models.RuleProvider, 0, len(result.Providers))
+	for _, provider := range result.Providers {
+		if provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name < providers[j].Name
+	})
+
+	r.mutex.Lock()
+	if r.isActive {
+		r.providers = providers
+		r.lastUpdate = time.Now()
+	}
+	r.mutex.Unlock()
+}
+
+// updateProvidersTable redraws the providers table
+func (r *RuleProvidersPage) updateProvidersTable() {
+	r.mutex.RLock()
+	providers := r.providers
+	results := make(map[string]string, len(r.updateResults))
+	for name, result := range r.updateResults {
+		results[name] = result
+	}
+	selectedName := r.selectedName
+	r.mutex.RUnlock()
+
+	r.providersTable.Clear()
+	r.setTableHeaders()
+
+	selectedRow := 1
+	for i, provider := range providers {
+		row := i + 1
+		// Names from the core may contain [...], which tview reads as tags;
+		// the update results carry their own colors
+		cells := []struct {
+			text  string
+			color tcell.Color
+			align int
+		}{
+			{tview.Escape(provider.Name), tcell.ColorWhite, tview.AlignLeft},
+			{tview.Escape(provider.Behavior), tcell.ColorLightBlue, tview.AlignCenter},
+			{tview.Escape(provider.Format), tcell.ColorBlue, tview.AlignCenter},
+			{tview.Escape(provider.VehicleType), tcell.ColorPurple, tview.AlignCenter},
+			{fmt.Sprintf("%d", provider.RuleCount), tcell.ColorGreen, tview.AlignRight},
+			{formatAge(provider.UpdatedAt), ageColor(provider.UpdatedAt), tview.AlignRight},
+			{results[provider.Name], tcell.ColorWhite, tview.AlignLeft},
+		}
+
+		for col, cellData := range cells {
+			cell := tview.NewTableCell(cellData.text).
+				SetTextColor(cellData.color).
+				SetAlign(cellData.align)
+			cell.SetReference(provider.Name)
+			r.providersTable.SetCell(row, col, cell)
+		}
+
+		if provider.Name == selectedName {
+			selectedRow = row
+		}
+	}
+
+	if len(providers) > 0 {
+		r.providersTable.Select(selectedRow, 0)
+	}
+	r.updateStatus("")
+}
+
+// updateStatus shows the summary line, optionally followed by a message
+func (r *RuleProvidersPage) updateStatus(message string) {
+	r.mutex.RLock()
+	count := len(r.providers)
+	totalRules := 0
+	for _, provider := range r.providers {
+		totalRules += provider.RuleCount
+	}
+	lastUpdate := r.lastUpdate
+	unsupported := r.unsupported
+	r.mutex.RUnlock()
+
+	if unsupported != "" {
+		r.statusText.SetText(fmt.Sprintf("[yellow]%s[white]", unsupported))
+		return
+	}
+
+	if message == "" {
+		message = "[gray]Enter/U[white] 更新所选 [gray]A[white] 全部更新 [gray]R[white] 刷新"
+	}
+
+	r.statusText.SetText(fmt.Sprintf("[green]● %d[white] 个规则集 | %d 条规则 | [yellow]%s[white] 更新\n%s",
+		count, totalRules, lastUpdate.Format("15:04"), message))
+}
+
+// updateSelectedProvider updates the highlighted provider
+func (r *RuleProvidersPage) updateSelectedProvider() {
+	if r.selectedName == "" {
+		return
+	}
+	r.runUpdates([]string{r.selectedName})
+}
+
+// updateAllProviders updates every provider one after another
+func (r *RuleProvidersPage) updateAllProviders() {
+	r.mutex.RLock()
+	names := make([]string, 0, len(r.providers))
+	for _, provider := range r.providers {
+		names = append(names, provider.Name)
+	}
+	r.mutex.RUnlock()
+
+	r.runUpdates(names)
+}
+
+// runUpdates updates the named providers sequentially, reporting progress
+func (r *RuleProvidersPage) runUpdates(names []string) {
+	r.mutex.Lock()
+	if r.isUpdating || len(names) == 0 {
+		r.mutex.Unlock()
+		return
+	}
+	r.isUpdating = true
+	for _, name := range names {
+		r.updateResults[name] = "[gray]等待中[white]"
+	}
+	r.mutex.Unlock()
+
+	r.updateProvidersTable()
+
+	go func() {
+		defer func() {
+			r.mutex.Lock()
+			r.isUpdating = false
+			r.mutex.Unlock()
+		}()
+
+		failed := 0
+		for i, name := range names {
+			r.mutex.RLock()
+			isActive := r.isActive
+			r.mutex.RUnlock()
+			if !isActive {
+				return
+			}
+
+			r.setResult(name, "[yellow]更新中...[white]",
+				fmt.Sprintf("[yellow]正在更新 %d/%d: %s[white]", i+1, len(names), tview.Escape(name)))
+
+			if err := r.controller.UpdateRuleProvider(r.ctx, name); err != nil {
+				failed++
+				slog.Warn("Failed to update rule provider", "page", "ruleproviders", "provider", name, "err", err)
+				r.setResult(name, fmt.Sprintf("[red]✗ %s[white]", tview.Escape(describeError(err))), "")
+				continue
+			}
+			r.setResult(name, "[green]✓ 已更新[white]", "")
+		}
+
+		// Reload to pick up new rule counts and timestamps
+		r.loadProvidersData()
+
+		message := fmt.Sprintf("[green]已更新 %d 个规则集[white]", len(names))
+		if failed > 0 {
+			message = fmt.Sprintf("[red]%d/%d 个规则集更新失败[white]", failed, len(names))
+		}
+		ui.Updater.UpdateUi(func() {
+			r.updateProvidersTable()
+			r.updateStatus(message)
+		})
+	}()
+}
+
+// setResult records a provider's update result and refreshes the display
+func (r *RuleProvidersPage) setResult(name, result, message string) {
+	r.mutex.Lock()
+	r.updateResults[name] = result
+	r.mutex.Unlock()
+
+	ui.Updater.UpdateUi(func() {
+		r.updateProvidersTable()
+		if message != "" {
+			r.updateStatus(message)
+		}
+	})
+}
+
+// Refresh reloads the providers from the API
+func (r *RuleProvidersPage) Refresh() {
+	r.updateStatus("[yellow]正在刷新规则集...[white]")
+
+	go func() {
+		r.loadProvidersData()
+		ui.Updater.UpdateUi(func() {
+			r.updateProvidersTable()
+		})
+	}()
+}
+
+// showError shows an error message
+func (r *RuleProvidersPage) showError(message string) {
+	slog.Warn(message, "page", "ruleproviders")
+	go ui.Updater.UpdateUi(func() {
+		r.statusText.SetText(fmt.Sprintf("[red]错误:[white] %s", message))
+	})
+}
+
+// formatAge formats how long ago t was for display
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "刚刚"
+	case age < time.Hour:
+		return fmt.Sprintf("%d 分钟前", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%d 小时前", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%d 天前", int(age.Hours()/24))
+	}
+}
+
+// ageColor highlights stale data
+func ageColor(t time.Time) tcell.Color {
+	age := time.Since(t)
+	switch {
+	case t.IsZero():
+		return tcell.ColorGray
+	case age < 24*time.Hour:
+		return tcell.ColorGreen
+	case age < 7*24*time.Hour:
+		return tcell.ColorYellow
+	default:
+		return tcell.ColorRed
+	}
+}
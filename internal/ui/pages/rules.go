@@ -0,0 +1,546 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// allOption is the dropdown entry that disables a filter
+const allOption = "全部"
+
+// Rules represents the rules page
+type Rules struct {
+	*RulesPage
+}
+
+// Activate activates the rules page
+func (r *Rules) Activate() {
+	slog.Debug("Activating page", "page", "rules")
+	r.RulesPage.Activate()
+}
+
+// Deactivate deactivates the rules page
+func (r *Rules) Deactivate() {
+	slog.Debug("Deactivating page", "page", "rules")
+	r.RulesPage.Deactivate()
+}
+
+// RulesPage represents the rules audit page
+type RulesPage struct {
+	*tview.Flex
+	controller api.Controller
+
+	// Components
+	searchInput  *tview.InputField
+	typeFilter   *tview.DropDown
+	targetFilter *tview.DropDown
+	rulesTable   *tview.Table
+	summaryText  *tview.TextView
+	statusText   *tview.TextView
+
+	// Data
+	rules    []models.Rule
+	filtered []int // Indexes into rules matching the current filters
+
+	// Filters
+	query         string
+	typeValue     string
+	targetValue   string
+	typeOptions   []string
+	targetOptions []string
+
+	// Control
+	ctx    context.Context
+	cancel context.CancelFunc
+	mutex  sync.RWMutex
+
+	// State
+	isActive   bool
+	lastUpdate time.Time
+	loadError  string // Why the last load failed, kept until one succeeds
+
+	// Navigation
+	focusableComponents []tview.Primitive
+	currentFocusIndex   int
+}
+
+// NewRulesPage creates a new rules page
+func NewRulesPage(controller api.Controller) *RulesPage {
+	page := &RulesPage{
+		Flex:       tview.NewFlex(),
+		controller: controller,
+		rules:      make([]models.Rule, 0),
+	}
+
+	page.setupLayout()
+	page.setupEventHandlers()
+
+	return page
+}
+
+// Activate loads the rules when the page becomes active
+func (r *RulesPage) Activate() {
+	r.mutex.Lock()
+	r.isActive = true
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.mutex.Unlock()
+
+	r.loadRulesData()
+
+	go ui.Updater.UpdateUi(func() {
+		r.updateFilterOptions()
+		r.applyFilters()
+	})
+}
+
+// Deactivate unloads the rules to free memory
+func (r *RulesPage) Deactivate() {
+	r.mutex.Lock()
+	r.isActive = false
+	r.rules = make([]models.Rule, 0)
+	r.filtered = nil
+	r.mutex.Unlock()
+
+	// Abort a load still in flight
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// setupLayout sets up the rules page layout
+func (r *RulesPage) setupLayout() {
+	// Create components
+	r.createFilterBar()
+	r.createRulesTable()
+	r.createSummaryText()
+	r.createStatusText()
+
+	// Initialize navigation system
+	r.initializeNavigation()
+
+	// Filter bar (search + dropdowns)
+	filterBar := tview.NewFlex().SetDirection(tview.FlexColumn)
+	filterBar.SetBorder(true)
+	filterBar.SetTitle(" 筛选 ")
+	filterBar.AddItem(r.searchInput, 0, 2, false)
+	filterBar.AddItem(r.typeFilter, 0, 1, false)
+	filterBar.AddItem(r.targetFilter, 0, 1, false)
+
+	// Left panel (filter bar + table)
+	leftPanel := tview.NewFlex().SetDirection(tview.FlexRow)
+	leftPanel.AddItem(filterBar, 3, 0, false)
+	leftPanel.AddItem(r.rulesTable, 0, 1, true)
+
+	// Right panel (summary + status)
+	rightPanel := tview.NewFlex().SetDirection(tview.FlexRow)
+	rightPanel.AddItem(r.summaryText, 0, 1, false)
+	rightPanel.AddItem(r.statusText, 7, 0, false)
+
+	// Main layout
+	r.SetDirection(tview.FlexColumn)
+	r.AddItem(leftPanel, 0, 3, true)
+	r.AddItem(rightPanel, 40, 0, false)
+
+	r.SetBorder(true)
+	r.SetTitle(" 规则 ")
+}
+
+// createFilterBar creates the search input and filter dropdowns
+func (r *RulesPage) createFilterBar() {
+	r.searchInput = tview.NewInputField().
+		SetLabel("搜索: ").
+		SetPlaceholder("类型 / 内容 / 目标")
+
+	r.typeFilter = tview.NewDropDown().SetLabel(" 类型: ")
+	r.targetFilter = tview.NewDropDown().SetLabel(" 目标: ")
+}
+
+// createRulesTable creates the rules table
+func (r *RulesPage) createRulesTable() {
+	r.rulesTable = tview.NewTable().SetFixed(1, 0)
+	r.rulesTable.SetBorder(true)
+	r.rulesTable.SetTitle(" 规则列表 ")
+	r.rulesTable.SetSelectable(true, false)
+	r.setTableHeaders()
+}
+
+// setTableHeaders sets the rules table headers
+func (r *RulesPage) setTableHeaders() {
+	headers := []string{"#", "类型", "内容", "目标"}
+	for i, header := range headers {
+		cell := tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetAlign(tview.AlignCenter).
+			SetSelectable(false)
+		r.rulesTable.SetCell(0, i, cell)
+	}
+}
+
+// createSummaryText creates the per-target summary
+func (r *RulesPage) createSummaryText() {
+	r.summaryText = tview.NewTextView()
+	r.summaryText.SetBorder(true)
+	r.summaryText.SetTitle(" 目标统计 ")
+	r.summaryText.SetDynamicColors(true)
+	r.summaryText.SetScrollable(true)
+}
+
+// createStatusText creates the status display
+func (r *RulesPage) createStatusText() {
+	r.statusText = tview.NewTextView()
+	r.statusText.SetBorder(true)
+	r.statusText.SetTitle(" 状态 ")
+	r.statusText.SetDynamicColors(true)
+	r.statusText.SetText("加载中...")
+}
+
+// setupEventHandlers sets up event handlers
+func (r *RulesPage) setupEventHandlers() {
+	// Incremental search
+	r.searchInput.SetChangedFunc(func(text string) {
+		r.query = strings.ToLower(strings.TrimSpace(text))
+		r.applyFilters()
+	})
+	r.searchInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			r.focusRulesTable()
+		}
+	})
+
+	// Rules table input handler
+	r.rulesTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyCtrlR:
+			r.Refresh()
+			return nil
+		}
+
+		switch event.Rune() {
+		case '/':
+			r.focusSearchInput()
+			return nil
+		case 'r', 'R':
+			r.Refresh()
+			return nil
+		case 'c', 'C':
+			r.clearFilters()
+			return nil
+		}
+
+		return event
+	})
+}
+
+// loadRulesData loads data from /rules API
+func (r *RulesPage) loadRulesData() {
+	r.mutex.RLock()
+	isActive := r.isActive
+	r.mutex.RUnlock()
+
+	if !isActive {
+		return
+	}
+
+	rules, err := r.controller.GetRules(r.ctx)
+	if err != nil {
+		r.showError(fmt.Sprintf("获取规则失败: %s", describeError(err)))
+		return
+	}
+
+	r.mutex.Lock()
+	if r.isActive {
+		r.rules = rules
+		r.lastUpdate = time.Now()
+		r.loadError = ""
+	}
+	r.mutex.Unlock()
+}
+
+// updateFilterOptions rebuilds the type and target dropdowns from the loaded rules
+func (r *RulesPage) updateFilterOptions() {
+	r.mutex.RLock()
+	rules := r.rules
+	r.mutex.RUnlock()
+
+	types := make(map[string]struct{})
+	targets := make(map[string]struct{})
+	for _, rule := range rules {
+		types[rule.Type] = struct{}{}
+		targets[rule.Proxy] = struct{}{}
+	}
+
+	r.typeOptions = sortedOptions(types)
+	r.targetOptions = sortedOptions(targets)
+
+	// Options are shown escaped, so map the index back to the raw value
+	typeOptions, targetOptions := r.typeOptions, r.targetOptions
+	r.typeFilter.SetOptions(escapeOptions(typeOptions), func(text string, index int) {
+		r.typeValue = optionValue(typeOptions[index])
+		r.applyFilters()
+	})
+	r.targetFilter.SetOptions(escapeOptions(targetOptions), func(text string, index int) {
+		r.targetValue = optionValue(targetOptions[index])
+		r.applyFilters()
+	})
+
+	// Keep the previous selection if it still exists
+	r.typeFilter.SetCurrentOption(optionIndex(r.typeOptions, r.typeValue))
+	r.targetFilter.SetCurrentOption(optionIndex(r.targetOptions, r.targetValue))
+}
+
+// applyFilters recomputes the visible rules and redraws the table and summary
+func (r *RulesPage) applyFilters() {
+	r.mutex.Lock()
+	filtered := make([]int, 0, len(r.rules))
+	for i, rule := range r.rules {
+		if r.typeValue != "" && rule.Type != r.typeValue {
+			continue
+		}
+		if r.targetValue != "" && rule.Proxy != r.targetValue {
+			continue
+		}
+		if r.query != "" && !matchesRule(rule, r.query) {
+			continue
+		}
+		filtered = append(filtered, i)
+	}
+	r.filtered = filtered
+	r.mutex.Unlock()
+
+	r.updateRulesTable()
+	r.updateSummary()
+	r.updateStatus()
+}
+
+// matchesRule reports whether a rule contains the lowercase query
+func matchesRule(rule models.Rule, query string) bool {
+	return strings.Contains(strings.ToLower(rule.Payload), query) ||
+		strings.Contains(strings.ToLower(rule.Type), query) ||
+		strings.Contains(strings.ToLower(rule.Proxy), query)
+}
+
+// updateRulesTable redraws the rules table from the filtered rules
+func (r *RulesPage) updateRulesTable() {
+	r.mutex.RLock()
+	rules := r.rules
+	filtered := r.filtered
+	r.mutex.RUnlock()
+
+	r.rulesTable.Clear()
+	r.setTableHeaders()
+
+	for row, index := range filtered {
+		rule := rules[index]
+		cells := []struct {
+			text  string
+			color tcell.Color
+			align int
+		}{
+			{fmt.Sprintf("%d", index+1), tcell.ColorGray, tview.AlignRight},
+			{tview.Escape(rule.Type), tcell.ColorLightBlue, tview.AlignLeft},
+			{tview.Escape(rule.Payload), tcell.ColorWhite, tview.AlignLeft},
+			{tview.Escape(rule.Proxy), ruleTargetColor(rule.Proxy), tview.AlignLeft},
+		}
+
+		for col, cellData := range cells {
+			r.rulesTable.SetCell(row+1, col, tview.NewTableCell(cellData.text).
+				SetTextColor(cellData.color).
+				SetAlign(cellData.align))
+		}
+	}
+
+	if len(filtered) > 0 {
+		r.rulesTable.Select(1, 0)
+	}
+	r.rulesTable.ScrollToBeginning()
+}
+
+// ruleTargetColor returns the display color for a rule target
+func ruleTargetColor(target string) tcell.Color {
+	switch target {
+	case "DIRECT":
+		return tcell.ColorGreen
+	case "REJECT", "REJECT-DROP":
+		return tcell.ColorRed
+	default:
+		return tcell.ColorYellow
+	}
+}
+
+// updateSummary shows how many of the visible rules route to each target
+func (r *RulesPage) updateSummary() {
+	r.mutex.RLock()
+	counts := make(map[string]int)
+	for _, index := range r.filtered {
+		counts[r.rules[index].Proxy]++
+	}
+	total := len(r.filtered)
+	r.mutex.RUnlock()
+
+	targets := make([]string, 0, len(counts))
+	for target := range counts {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if counts[targets[i]] != counts[targets[j]] {
+			return counts[targets[i]] > counts[targets[j]]
+		}
+		return targets[i] < targets[j]
+	})
+
+	var content strings.Builder
+	for _, target := range targets {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(counts[target]) * 100 / float64(total)
+		}
+		fmt.Fprintf(&content, "[yellow]%-6d[white] %5.1f%%  %s\n", counts[target], percent, tview.Escape(target))
+	}
+	r.summaryText.SetText(content.String())
+	r.summaryText.ScrollToBeginning()
+}
+
+// updateStatus updates the status text
+func (r *RulesPage) updateStatus() {
+	r.mutex.RLock()
+	total := len(r.rules)
+	shown := len(r.filtered)
+	lastUpdate := r.lastUpdate
+	loadError := r.loadError
+	r.mutex.RUnlock()
+
+	summary := fmt.Sprintf("[green]● %d[white] / %d 条规则 | [yellow]%s[white] 更新", shown, total, lastUpdate.Format("15:04"))
+	if loadError != "" {
+		summary = fmt.Sprintf("[red]错误:[white] %s", tview.Escape(loadError))
+	}
+
+	r.statusText.SetText(summary + `
+
+[gray]快捷键:[white]
+[yellow]/[white] 搜索 [yellow]C[white] 清除筛选 [yellow]R[white] 刷新
+[yellow]TAB[white] 切换组件`)
+}
+
+// clearFilters resets the search query and dropdown filters
+func (r *RulesPage) clearFilters() {
+	r.query = ""
+	r.typeValue = ""
+	r.targetValue = ""
+	r.searchInput.SetText("")
+	r.typeFilter.SetCurrentOption(0)
+	r.targetFilter.SetCurrentOption(0)
+	r.applyFilters()
+}
+
+// Refresh reloads the rules from the API
+func (r *RulesPage) Refresh() {
+	r.statusText.SetText("[yellow]正在刷新规则...[white]")
+
+	go func() {
+		r.loadRulesData()
+		ui.Updater.UpdateUi(func() {
+			r.updateFilterOptions()
+			r.applyFilters()
+		})
+	}()
+}
+
+// showError keeps an error message in the status until the next
+// successful load, so the redraw that follows a load doesn't hide it
+func (r *RulesPage) showError(message string) {
+	slog.Warn(message, "page", "rules")
+	r.mutex.Lock()
+	r.loadError = message
+	r.mutex.Unlock()
+}
+
+// sortedOptions returns dropdown options with the "all" entry first
+func sortedOptions(values map[string]struct{}) []string {
+	options := make([]string, 0, len(values))
+	for value := range values {
+		options = append(options, value)
+	}
+	sort.Strings(options)
+	return append([]string{allOption}, options...)
+}
+
+// escapeOptions returns options ready to be shown in a dropdown
+func escapeOptions(options []string) []string {
+	escaped := make([]string, len(options))
+	for i, option := range options {
+		escaped[i] = tview.Escape(option)
+	}
+	return escaped
+}
+
+// optionValue maps a dropdown option to a filter value
+func optionValue(option string) string {
+	if option == allOption {
+		return ""
+	}
+	return option
+}
+
+// optionIndex finds value in options, defaulting to the "all" entry
+func optionIndex(options []string, value string) int {
+	for i, option := range options {
+		if value != "" && option == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// Navigation methods
+
+// initializeNavigation initializes the navigation system
+func (r *RulesPage) initializeNavigation() {
+	r.focusableComponents = []tview.Primitive{r.rulesTable, r.searchInput, r.typeFilter, r.targetFilter}
+	r.currentFocusIndex = 0
+	r.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTAB:
+			r.switchToNextComponent()
+			return nil
+		case tcell.KeyBacktab:
+			r.switchToPrevComponent()
+			return nil
+		}
+		return event
+	})
+}
+
+// switchToNextComponent switches focus to the next component
+func (r *RulesPage) switchToNextComponent() {
+	r.currentFocusIndex = (r.currentFocusIndex + 1) % len(r.focusableComponents)
+	ui.Updater.SetFocus(r.focusableComponents[r.currentFocusIndex])
+}
+
+// switchToPrevComponent switches focus to the previous component
+func (r *RulesPage) switchToPrevComponent() {
+	r.currentFocusIndex = (r.currentFocusIndex - 1 + len(r.focusableComponents)) % len(r.focusableComponents)
+	ui.Updater.SetFocus(r.focusableComponents[r.currentFocusIndex])
+}
+
+// focusRulesTable focuses the rules table
+func (r *RulesPage) focusRulesTable() {
+	r.currentFocusIndex = 0
+	ui.Updater.SetFocus(r.rulesTable)
+}
+
+// focusSearchInput focuses the search input
+func (r *RulesPage) focusSearchInput() {
+	r.currentFocusIndex = 1
+	ui.Updater.SetFocus(r.searchInput)
+}
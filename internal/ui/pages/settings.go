@@ -1,13 +1,144 @@
 package pages
 
 import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
 	"mihomoTui/internal/config"
+	"mihomoTui/internal/logging"
+	"mihomoTui/internal/ui"
 
 	"github.com/rivo/tview"
 )
 
 // Settings represents the settings page
 type Settings struct {
-	*tview.TextView
+	*SettingsPage
+}
+
+// Activate activates the settings page
+func (s *Settings) Activate() {
+	slog.Debug("Activating page", "page", "settings")
+	s.SettingsPage.Activate()
+}
+
+// Deactivate deactivates the settings page
+func (s *Settings) Deactivate() {
+	slog.Debug("Deactivating page", "page", "settings")
+	s.SettingsPage.Deactivate()
+}
+
+// SettingsPage edits the appearance and can run the setup wizard again
+type SettingsPage struct {
+	*tview.Flex
 	configManager *config.Manager
+
+	// Components
+	form       *tview.Form
+	statusText *tview.TextView
+
+	// Called after the appearance settings were saved
+	onApply func()
+
+	// Called when the user asks for the setup wizard
+	onRunWizard func()
+}
+
+// NewSettingsPage creates a new settings page
+func NewSettingsPage(configManager *config.Manager, onApply, onRunWizard func()) *SettingsPage {
+	page := &SettingsPage{
+		Flex:          tview.NewFlex(),
+		configManager: configManager,
+		form:          tview.NewForm(),
+		statusText:    tview.NewTextView(),
+		onApply:       onApply,
+		onRunWizard:   onRunWizard,
+	}
+
+	page.setupUI()
+	return page
+}
+
+// setupUI initializes the settings page UI
+func (s *SettingsPage) setupUI() {
+	s.form.AddDropDown("界面语言", ui.ChoiceNames(ui.Languages), 0, nil)
+	s.form.AddDropDown("主题", ui.ChoiceNames(ui.Themes), 0, nil)
+	s.form.AddDropDown("日志级别", logging.Levels, 0, nil)
+	s.form.AddButton("保存", s.save)
+	s.form.AddButton("设置向导", func() {
+		s.onRunWizard()
+	})
+	s.form.SetBorder(true)
+	s.form.SetTitle(" 设置 ")
+	s.form.SetButtonsAlign(tview.AlignCenter)
+
+	s.statusText.SetBorder(true)
+	s.statusText.SetTitle(" 状态 ")
+	s.statusText.SetDynamicColors(true)
+
+	s.SetDirection(tview.FlexColumn)
+	s.AddItem(s.form, 0, 3, true)
+	s.AddItem(s.statusText, 0, 1, false)
+}
+
+// Activate loads the saved settings into the form
+func (s *SettingsPage) Activate() {
+	ui.Updater.UpdateUi(s.updateForm)
+}
+
+// Deactivate deactivates the settings page
+func (s *SettingsPage) Deactivate() {
+}
+
+// updateForm shows the saved settings
+func (s *SettingsPage) updateForm() {
+	cfg := s.configManager.Get().UI
+	s.form.GetFormItemByLabel("界面语言").(*tview.DropDown).SetCurrentOption(ui.ChoiceIndex(ui.Languages, cfg.Language))
+	s.form.GetFormItemByLabel("主题").(*tview.DropDown).SetCurrentOption(ui.ChoiceIndex(ui.Themes, cfg.Theme))
+	s.form.GetFormItemByLabel("日志级别").(*tview.DropDown).SetCurrentOption(logLevelIndex(s.configManager.Get().Log.Level))
+	s.showStatus(fmt.Sprintf("[gray]配置文件: %s\n日志文件: %s[white]",
+		tview.Escape(s.configManager.GetConfigPath()), tview.Escape(logging.Path())))
+}
+
+// save stores the appearance settings
+func (s *SettingsPage) save() {
+	language, _ := s.form.GetFormItemByLabel("界面语言").(*tview.DropDown).GetCurrentOption()
+	theme, _ := s.form.GetFormItemByLabel("主题").(*tview.DropDown).GetCurrentOption()
+	_, level := s.form.GetFormItemByLabel("日志级别").(*tview.DropDown).GetCurrentOption()
+
+	oldConfig := s.configManager.Get()
+	newConfig := oldConfig.Clone()
+	newConfig.UI.Language = ui.Languages[max(language, 0)].Value
+	newConfig.UI.Theme = ui.Themes[max(theme, 0)].Value
+	newConfig.Log.Level = level
+
+	s.configManager.Set(newConfig)
+	if err := s.configManager.Save(); err != nil {
+		s.configManager.Set(oldConfig)
+		s.showStatus(fmt.Sprintf("[red]保存失败: %v[white]", err))
+		return
+	}
+	logging.SetLevel(level)
+	s.onApply()
+
+	if newConfig.UI.Theme != oldConfig.UI.Theme {
+		s.showStatus("[green]设置已保存，主题将在重启后完全生效[white]")
+	} else {
+		s.showStatus("[green]设置已保存[white]")
+	}
+}
+
+// logLevelIndex returns the option of the saved log level, info when unset
+func logLevelIndex(level string) int {
+	if i := slices.Index(logging.Levels, strings.ToLower(level)); i >= 0 {
+		return i
+	}
+	return slices.Index(logging.Levels, "info")
+}
+
+// showStatus displays a status message
+func (s *SettingsPage) showStatus(message string) {
+	s.statusText.SetText(message)
 }
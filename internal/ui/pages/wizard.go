@@ -0,0 +1,305 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/discovery"
+	"mihomoTui/internal/models"
+	"mihomoTui/internal/ui"
+
+	"github.com/rivo/tview"
+)
+
+// WizardModal is the overlay name of the setup wizard
+const WizardModal = "wizard"
+
+// wizardSteps names the wizard pages in order
+var wizardSteps = []string{"controller", "secret", "appearance", "finish"}
+
+// wizardFocus is the form item focused when each step opens, skipping the
+// explanatory text; past the last item it is the first button
+var wizardFocus = []int{1, 1, 0, 1}
+
+// Wizard walks the user through connecting to a core and choosing the
+// appearance, then saves the result to the active profile
+type Wizard struct {
+	*tview.Flex
+	configManager *config.Manager
+
+	// Components
+	steps          *tview.Pages
+	controllerForm *tview.Form
+	secretForm     *tview.Form
+	appearanceForm *tview.Form
+	finishForm     *tview.Form
+	stepForms      []*tview.Form // In wizardSteps order
+	statusText     *tview.TextView
+
+	// Data
+	api      config.APIConfig
+	ui       config.UIConfig
+	version  string // Core version, set once the connection was verified
+	verified bool
+
+	// State
+	step int
+
+	// Called with true after the settings were saved, or false when the
+	// wizard was closed without saving
+	onClose func(saved bool)
+}
+
+// NewWizard creates a setup wizard for the active profile
+func NewWizard(configManager *config.Manager, onClose func(saved bool)) *Wizard {
+	cfg := configManager.Get()
+	w := &Wizard{
+		Flex:          tview.NewFlex(),
+		configManager: configManager,
+		steps:         tview.NewPages(),
+		statusText:    tview.NewTextView(),
+		api:           cfg.Active().API,
+		ui:            cfg.UI,
+		onClose:       onClose,
+	}
+
+	w.setupLayout()
+	w.showStep(0)
+	return w
+}
+
+// Show opens the wizard as a modal. Must be called from the UI goroutine.
+func (w *Wizard) Show() {
+	ui.Updater.ShowModal(WizardModal, ui.Centered(w, 72, 16))
+}
+
+// setupLayout builds one form per step above a shared status line
+func (w *Wizard) setupLayout() {
+	w.controllerForm = w.newStepForm()
+	w.controllerForm.AddTextView("", "请填写 mihomo 外部控制器地址，或自动查找本机核心", 0, 1, false, false)
+	w.controllerForm.AddInputField("API地址", w.api.BaseURL, 50, nil, nil)
+	w.controllerForm.GetFormItemByLabel("API地址").(*tview.InputField).SetPlaceholder("http://127.0.0.1:9090 或 unix:///path/to/mihomo.sock")
+	w.controllerForm.AddButton("下一步", w.submitController)
+	w.controllerForm.AddButton("自动发现", w.discover)
+	w.controllerForm.AddButton("跳过向导", w.cancel)
+
+	w.secretForm = w.newStepForm()
+	w.secretForm.AddTextView("", "请填写配置文件中的 secret，未设置时留空", 0, 1, false, false)
+	w.secretForm.AddPasswordField("API密钥", w.api.Secret, 50, '*', nil)
+	w.secretForm.AddButton("验证并继续", w.verifySecret)
+	w.secretForm.AddButton("上一步", w.back)
+	w.secretForm.AddButton("跳过验证", func() {
+		w.readSecret()
+		w.verified = false
+		w.showStep(2)
+	})
+
+	w.appearanceForm = w.newStepForm()
+	w.appearanceForm.AddDropDown("界面语言", ui.ChoiceNames(ui.Languages), ui.ChoiceIndex(ui.Languages, w.ui.Language), nil)
+	w.appearanceForm.AddDropDown("主题", ui.ChoiceNames(ui.Themes), ui.ChoiceIndex(ui.Themes, w.ui.Theme), nil)
+	w.appearanceForm.AddButton("下一步", func() {
+		w.readAppearance()
+		w.showStep(3)
+	})
+	w.appearanceForm.AddButton("上一步", w.back)
+
+	w.finishForm = w.newStepForm()
+	w.finishForm.AddTextView("", "", 0, 5, true, false)
+	w.finishForm.AddButton("完成", w.finish)
+	w.finishForm.AddButton("上一步", w.back)
+
+	w.stepForms = []*tview.Form{w.controllerForm, w.secretForm, w.appearanceForm, w.finishForm}
+	for i, form := range w.stepForms {
+		w.steps.AddPage(wizardSteps[i], form, true, false)
+	}
+
+	w.statusText.SetDynamicColors(true)
+	w.statusText.SetBorderPadding(0, 0, 1, 1)
+
+	w.SetDirection(tview.FlexRow)
+	w.AddItem(w.steps, 0, 1, true)
+	w.AddItem(w.statusText, 2, 0, false)
+	w.SetBorder(true)
+}
+
+// newStepForm creates the form of a step; Escape closes the wizard
+func (w *Wizard) newStepForm() *tview.Form {
+	form := tview.NewForm()
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.SetCancelFunc(w.cancel)
+	return form
+}
+
+// showStep switches to the given step and focuses its form
+func (w *Wizard) showStep(step int) {
+	if step == 3 {
+		w.updateSummary()
+	}
+
+	// Pick the item first, so switching pages hands the focus to it
+	w.stepForms[step].SetFocus(wizardFocus[step])
+
+	w.step = step
+	w.steps.SwitchToPage(wizardSteps[step])
+	w.SetTitle(fmt.Sprintf(" 设置向导 (%d/%d) ", step+1, len(wizardSteps)))
+	w.showStatus("")
+}
+
+// back returns to the previous step
+func (w *Wizard) back() {
+	if w.step > 0 {
+		w.showStep(w.step - 1)
+	}
+}
+
+// submitController validates the address and moves on to the secret
+func (w *Wizard) submitController() {
+	address := strings.TrimSpace(w.controllerForm.GetFormItemByLabel("API地址").(*tview.InputField).GetText())
+	apiConfig := w.api
+	apiConfig.BaseURL = address
+	if err := config.ValidateAPI(apiConfig); err != nil {
+		w.showStatus(fmt.Sprintf("[red]地址无效: %v[white]", err))
+		return
+	}
+
+	if address != w.api.BaseURL {
+		w.verified = false
+	}
+	w.api.BaseURL = address
+	w.showStep(1)
+}
+
+// discover looks for local cores and fills in the one the user picks
+func (w *Wizard) discover() {
+	w.showStatus("[yellow]正在查找本机核心...[white]")
+
+	go func() {
+		candidates := discovery.Discover(context.Background())
+
+		ui.Updater.UpdateUi(func() {
+			if len(candidates) == 0 {
+				w.showStatus("[yellow]未找到核心，请确认核心已启动或手动填写地址[white]")
+				return
+			}
+
+			labels := make([]string, len(candidates))
+			byLabel := make(map[string]discovery.Candidate, len(candidates))
+			for i, candidate := range candidates {
+				labels[i] = candidateLabel(candidate)
+				byLabel[labels[i]] = candidate
+			}
+
+			w.showStatus(fmt.Sprintf("[green]找到 %d 个核心[white]", len(candidates)))
+			ui.Updater.ShowPicker(discoveryModal, "选择核心", labels, "", func(label string) {
+				candidate := byLabel[label]
+				w.controllerForm.GetFormItemByLabel("API地址").(*tview.InputField).SetText(candidate.API.BaseURL)
+				w.secretForm.GetFormItemByLabel("API密钥").(*tview.InputField).SetText(candidate.API.Secret)
+				w.showStatus(fmt.Sprintf("[green]已选择 %s[white]", tview.Escape(candidate.API.BaseURL)))
+			})
+		})
+	}()
+}
+
+// readSecret copies the secret field into the settings being edited
+func (w *Wizard) readSecret() {
+	w.api.Secret = w.secretForm.GetFormItemByLabel("API密钥").(*tview.InputField).GetText()
+}
+
+// verifySecret connects with the entered secret and moves on once the
+// core accepted it
+func (w *Wizard) verifySecret() {
+	w.readSecret()
+	apiConfig := w.api
+	w.showStatus(fmt.Sprintf("[yellow]正在连接 %s...[white]", tview.Escape(apiConfig.BaseURL)))
+
+	go func() {
+		client := api.NewHttpClient(apiConfig, 5*time.Second)
+		defer client.Close()
+		ctx := context.Background()
+
+		var version string
+		err := client.HealthCheck(ctx)
+		if err == nil {
+			var info *models.Version
+			if info, err = client.GetVersion(ctx); err == nil {
+				version = info.Version
+			}
+		}
+
+		ui.Updater.UpdateUi(func() {
+			if err != nil {
+				w.showStatus(fmt.Sprintf("[red]验证失败: %s[white]", describeError(err)))
+				return
+			}
+			w.verified = true
+			w.version = version
+			w.showStep(2)
+		})
+	}()
+}
+
+// readAppearance copies the drop-downs into the settings being edited
+func (w *Wizard) readAppearance() {
+	language, _ := w.appearanceForm.GetFormItemByLabel("界面语言").(*tview.DropDown).GetCurrentOption()
+	theme, _ := w.appearanceForm.GetFormItemByLabel("主题").(*tview.DropDown).GetCurrentOption()
+	w.ui.Language = ui.Languages[max(language, 0)].Value
+	w.ui.Theme = ui.Themes[max(theme, 0)].Value
+}
+
+// updateSummary lists what finishing the wizard will save
+func (w *Wizard) updateSummary() {
+	connection := "[yellow]未验证[white]"
+	if w.verified {
+		connection = fmt.Sprintf("[green]已验证，核心版本 %s[white]", tview.Escape(w.version))
+	}
+	secret := "未设置"
+	if w.api.Secret != "" {
+		secret = "已设置"
+	}
+
+	summary := fmt.Sprintf("API地址: %s\nAPI密钥: %s\n连接: %s\n语言: %s  主题: %s",
+		tview.Escape(w.api.BaseURL), secret, connection,
+		ui.Languages[ui.ChoiceIndex(ui.Languages, w.ui.Language)].Name,
+		ui.Themes[ui.ChoiceIndex(ui.Themes, w.ui.Theme)].Name)
+	w.finishForm.GetFormItem(0).(*tview.TextView).SetText(summary)
+}
+
+// finish saves the settings to the active profile and closes the wizard
+func (w *Wizard) finish() {
+	if err := config.ValidateAPI(w.api); err != nil {
+		w.showStatus(fmt.Sprintf("[red]配置无效: %v[white]", err))
+		return
+	}
+
+	newConfig := w.configManager.Get().Clone()
+	newConfig.Active().API = w.api
+	newConfig.UI = w.ui
+
+	oldConfig := w.configManager.Get()
+	w.configManager.Set(newConfig)
+	if err := w.configManager.Save(); err != nil {
+		w.configManager.Set(oldConfig)
+		w.showStatus(fmt.Sprintf("[red]保存失败: %v[white]", err))
+		return
+	}
+	slog.Info("Setup wizard saved profile", "profile", newConfig.Active().Name)
+
+	ui.Updater.HideModal(WizardModal)
+	w.onClose(true)
+}
+
+// cancel closes the wizard without saving
+func (w *Wizard) cancel() {
+	ui.Updater.HideModal(WizardModal)
+	w.onClose(false)
+}
+
+// showStatus displays a status message below the current step
+func (w *Wizard) showStatus(message string) {
+	w.statusText.SetText(message)
+}
@@ -0,0 +1,308 @@
+package ui
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/models"
+)
+
+// Intervals of the shared pollers and streams
+const (
+	configPollInterval  = 2 * time.Second
+	connectionsInterval = time.Second
+)
+
+// Store holds the core state shared by pages and components. Each resource
+// is fetched by a single poller or stream, however many watch it.
+type Store struct {
+	Config      *Resource[*models.Config]
+	Proxies     *Resource[map[string]*models.ProxyProvider] // Grouped as /providers/proxies reports them
+	Connections *Resource[*models.ConnectionsDiff]
+	Traffic     *Resource[*models.Traffic]
+	Memory      *Resource[*models.MemoryUsage]
+}
+
+// NewStore creates a store fed by controller. Nothing is fetched until a
+// resource gets its first subscriber.
+func NewStore(controller api.Controller) *Store {
+	return &Store{
+		Config: newResource(poll(configPollInterval, controller.GetConfig)),
+		// Proxies only change on request, so they are reloaded through
+		// Refresh rather than polled
+		Proxies: newResource(poll(0, func(ctx context.Context) (map[string]*models.ProxyProvider, error) {
+			return loadProxies(ctx, controller)
+		})),
+		Connections: newResource(stream("connections", func(ctx context.Context, callback func(*models.ConnectionsDiff)) error {
+			return controller.WatchConnections(ctx, connectionsInterval, callback)
+		})),
+		Traffic: newResource(stream("traffic", controller.StreamTraffic)),
+		Memory: newResource(requires(controller, api.FeatureMemory,
+			stream("memory", controller.StreamMemoryUsage))),
+	}
+}
+
+// Reset forgets every value and restarts running feeds, so nothing from
+// the previous core is shown after switching controllers
+func (s *Store) Reset() {
+	s.Config.reset()
+	s.Proxies.reset()
+	s.Connections.reset()
+	s.Traffic.reset()
+	s.Memory.reset()
+}
+
+// feed produces the values of a resource until ctx is cancelled. refresh
+// fires when a fresh value is wanted at once.
+type feed[T any] func(ctx context.Context, refresh <-chan struct{}, publish func(T), fail func(error))
+
+// subscriber receives the updates of a resource
+type subscriber[T any] struct {
+	onUpdate func(T)
+	onError  func(error)
+}
+
+// Resource is one piece of core state. The first subscriber starts its
+// feed and the last one to leave stops it; the latest value is kept.
+type Resource[T any] struct {
+	feed    feed[T]
+	refresh chan struct{}
+
+	mutex       sync.Mutex
+	value       T
+	loaded      bool
+	subscribers map[int]subscriber[T]
+	nextID      int
+	stop        context.CancelFunc
+	generation  int // Bumped when the feed stops, to drop its late values
+}
+
+// newResource creates a resource fed by feed
+func newResource[T any](feed feed[T]) *Resource[T] {
+	return &Resource[T]{
+		feed:        feed,
+		refresh:     make(chan struct{}, 1),
+		subscribers: make(map[int]subscriber[T]),
+	}
+}
+
+// Subscribe calls onUpdate with the latest value, if there is one, and with
+// every new value until the returned function is called. onError, if set,
+// is called when fetching fails. The latest value is delivered on the
+// caller's goroutine and the rest on the feed's, so callbacks waiting for
+// the UI thread must not be subscribed from it.
+func (r *Resource[T]) Subscribe(onUpdate func(T), onError func(error)) (unsubscribe func()) {
+	r.mutex.Lock()
+	id := r.nextID
+	r.nextID++
+	r.subscribers[id] = subscriber[T]{onUpdate: onUpdate, onError: onError}
+	if r.stop == nil {
+		r.start()
+	}
+	value, loaded := r.value, r.loaded
+	r.mutex.Unlock()
+
+	if loaded {
+		onUpdate(value)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+
+			delete(r.subscribers, id)
+			if len(r.subscribers) == 0 && r.stop != nil {
+				r.stop()
+				r.stop = nil
+				r.generation++
+			}
+		})
+	}
+}
+
+// Get returns the latest value and whether there is one
+func (r *Resource[T]) Get() (T, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.value, r.loaded
+}
+
+// Refresh asks a polled resource to fetch now, for instance after changing
+// it. Streamed resources are always current and ignore it.
+func (r *Resource[T]) Refresh() {
+	select {
+	case r.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// start runs the feed. The caller holds the mutex.
+func (r *Resource[T]) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+	generation := r.generation
+
+	publish := func(value T) {
+		r.mutex.Lock()
+		if r.generation != generation {
+			r.mutex.Unlock()
+			return
+		}
+		r.value, r.loaded = value, true
+		subscribers := r.snapshot()
+		r.mutex.Unlock()
+
+		for _, s := range subscribers {
+			s.onUpdate(value)
+		}
+	}
+	fail := func(err error) {
+		r.mutex.Lock()
+		if r.generation != generation {
+			r.mutex.Unlock()
+			return
+		}
+		subscribers := r.snapshot()
+		r.mutex.Unlock()
+
+		for _, s := range subscribers {
+			if s.onError != nil {
+				s.onError(err)
+			}
+		}
+	}
+
+	go r.feed(ctx, r.refresh, publish, fail)
+}
+
+// snapshot copies the subscribers so they are called without the lock.
+// The caller holds the mutex.
+func (r *Resource[T]) snapshot() []subscriber[T] {
+	subscribers := make([]subscriber[T], 0, len(r.subscribers))
+	for _, s := range r.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
+
+// reset forgets the latest value. A running feed is restarted so values
+// it was still fetching are dropped.
+func (r *Resource[T]) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var zero T
+	r.value, r.loaded = zero, false
+	if r.stop != nil {
+		r.stop()
+		r.generation++
+		r.start()
+	}
+}
+
+// poll fetches a value right away, then every interval and on refresh.
+// A zero interval only fetches on refresh.
+func poll[T any](interval time.Duration, fetch func(context.Context) (T, error)) feed[T] {
+	return func(ctx context.Context, refresh <-chan struct{}, publish func(T), fail func(error)) {
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			value, err := fetch(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				fail(err)
+			} else {
+				publish(value)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+			case <-refresh:
+			}
+		}
+	}
+}
+
+// stream keeps a supervised stream open, publishing every message
+func stream[T any](name string, connect func(ctx context.Context, callback func(T)) error) feed[T] {
+	return func(ctx context.Context, _ <-chan struct{}, publish func(T), fail func(error)) {
+		api.Supervise(ctx, name, func(ctx context.Context, alive func()) error {
+			return connect(ctx, func(value T) {
+				alive()
+				publish(value)
+			})
+		}, fail)
+	}
+}
+
+// requires runs feed only on cores with feature; elsewhere the resource
+// never gets a value
+func requires[T any](controller api.Controller, feature api.Feature, feed feed[T]) feed[T] {
+	return func(ctx context.Context, refresh <-chan struct{}, publish func(T), fail func(error)) {
+		if !controller.Capabilities(ctx).Has(feature) {
+			return
+		}
+		feed(ctx, refresh, publish, fail)
+	}
+}
+
+// loadProxies loads the proxy groups. Cores without providers still list
+// groups and their members through /proxies.
+func loadProxies(ctx context.Context, controller api.Controller) (map[string]*models.ProxyProvider, error) {
+	if controller.Capabilities(ctx).Has(api.FeatureProxyProviders) {
+		result, err := controller.GetProviders(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return result.Providers, nil
+	}
+
+	proxies, err := controller.GetProxies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return providersFromProxies(proxies), nil
+}
+
+// providersFromProxies arranges /proxies the way /providers/proxies reports
+// it: a "default" provider holding every proxy, plus one per group
+func providersFromProxies(proxies map[string]*models.Proxy) map[string]*models.ProxyProvider {
+	all := make([]*models.Proxy, 0, len(proxies))
+	for _, proxy := range proxies {
+		all = append(all, proxy)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	providers := map[string]*models.ProxyProvider{
+		"default": {Name: "default", VehicleType: "Compatible", Proxies: all},
+	}
+	for name, proxy := range proxies {
+		if len(proxy.All) == 0 {
+			continue
+		}
+		members := make([]*models.Proxy, 0, len(proxy.All))
+		for _, member := range proxy.All {
+			if memberProxy, ok := proxies[member]; ok {
+				members = append(members, memberProxy)
+			}
+		}
+		providers[name] = &models.ProxyProvider{Name: name, VehicleType: "Compatible", Proxies: members}
+	}
+	return providers
+}
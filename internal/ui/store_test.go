@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"mihomoTui/internal/api"
+	"mihomoTui/internal/api/apitest"
+	"mihomoTui/internal/config"
+	"mihomoTui/internal/models"
+)
+
+// newTestStore starts a fake controller and a store fed by it
+func newTestStore(t *testing.T) (*apitest.Server, *Store) {
+	t.Helper()
+	server := apitest.NewServer()
+	t.Cleanup(server.Close)
+
+	client := api.NewHttpClient(config.APIConfig{BaseURL: server.URL}, 5*time.Second)
+	t.Cleanup(client.Close)
+	return server, NewStore(client)
+}
+
+// receive waits for the next value on ch
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a value")
+		var zero T
+		return zero
+	}
+}
+
+// countRequests counts the GET requests made for path
+func countRequests(server *apitest.Server, path string) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if request.Method == "GET" && request.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+func TestResourceSharesOneFeed(t *testing.T) {
+	server, store := newTestStore(t)
+
+	first := make(chan map[string]*models.ProxyProvider, 4)
+	stopFirst := store.Proxies.Subscribe(func(value map[string]*models.ProxyProvider) { first <- value }, nil)
+	receive(t, first)
+	fetched := countRequests(server, "/providers/proxies")
+
+	// A later subscriber gets the latest value without another fetch
+	second := make(chan map[string]*models.ProxyProvider, 4)
+	stopSecond := store.Proxies.Subscribe(func(value map[string]*models.ProxyProvider) { second <- value }, nil)
+	receive(t, second)
+	if n := countRequests(server, "/providers/proxies"); n != fetched {
+		t.Errorf("second subscriber fetched proxies %d more times", n-fetched)
+	}
+
+	// Refresh reaches every subscriber
+	store.Proxies.Refresh()
+	receive(t, first)
+	receive(t, second)
+
+	stopFirst()
+	stopSecond()
+	if _, loaded := store.Proxies.Get(); !loaded {
+		t.Error("Get lost the latest value after the last subscriber left")
+	}
+}
+
+func TestResourceRefreshAndReset(t *testing.T) {
+	server, store := newTestStore(t)
+
+	configs := make(chan *models.Config, 4)
+	stop := store.Config.Subscribe(func(value *models.Config) { configs <- value }, nil)
+	defer stop()
+	if config := receive(t, configs); config.Mode != "rule" {
+		t.Fatalf("mode = %q, want rule", config.Mode)
+	}
+
+	server.Update(func(state *apitest.State) {
+		state.Config.Mode = "global"
+	})
+	store.Config.Refresh()
+	if config := receive(t, configs); config.Mode != "global" {
+		t.Errorf("mode after Refresh = %q, want global", config.Mode)
+	}
+
+	// Reset drops the value and fetches again for the subscribers
+	store.Reset()
+	if _, loaded := store.Config.Get(); loaded {
+		t.Error("Get returned a value right after Reset")
+	}
+	receive(t, configs)
+}
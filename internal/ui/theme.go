@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Choice is a setting value with the name shown for it
+type Choice struct {
+	Value string
+	Name  string
+}
+
+// Languages of the interface. Only the navigation is translated so far;
+// pages stay in Chinese.
+const (
+	LanguageChinese = "zh-CN"
+	LanguageEnglish = "en"
+)
+
+// Languages lists the selectable languages, default first
+var Languages = []Choice{
+	{Value: LanguageChinese, Name: "简体中文"},
+	{Value: LanguageEnglish, Name: "English"},
+}
+
+// Color themes
+const (
+	ThemeDark     = "dark"
+	ThemeTerminal = "terminal" // Keeps the terminal's own background
+)
+
+// Themes lists the selectable themes, default first
+var Themes = []Choice{
+	{Value: ThemeDark, Name: "深色"},
+	{Value: ThemeTerminal, Name: "跟随终端背景"},
+}
+
+// defaultStyles are tview's styles before any theme was applied
+var defaultStyles = tview.Styles
+
+// ApplyTheme sets the styles of primitives created from now on. Existing
+// primitives keep their colors, so the theme is best applied before the
+// layout is built.
+func ApplyTheme(theme string) {
+	styles := defaultStyles
+	if theme == ThemeTerminal {
+		styles.PrimitiveBackgroundColor = tcell.ColorDefault
+	}
+	tview.Styles = styles
+}
+
+// ChoiceIndex returns the position of value in choices, or 0 for the
+// default when it is unknown
+func ChoiceIndex(choices []Choice, value string) int {
+	for i, choice := range choices {
+		if choice.Value == value {
+			return i
+		}
+	}
+	return 0
+}
+
+// ChoiceNames returns the names of choices for a drop-down
+func ChoiceNames(choices []Choice) []string {
+	names := make([]string, len(choices))
+	for i, choice := range choices {
+		names[i] = choice.Name
+	}
+	return names
+}
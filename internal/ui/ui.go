@@ -11,14 +11,12 @@ var (
 	Updater *UiUpdater
 )
 
-type statusBar interface {
-	GetCurrentMode() string
-	UpdateConfig(config interface{})
-}
-
 type UiUpdater struct {
-	app     *tview.Application
-	statBar statusBar
+	app *tview.Application
+
+	// Modal overlay
+	overlay   *tview.Pages
+	lastFocus map[string]tview.Primitive // Focus each open modal replaced
 }
 
 func InitUpdater(app *tview.Application) {
@@ -29,23 +27,6 @@ func InitUpdater(app *tview.Application) {
 	})
 }
 
-func (u *UiUpdater) SetStatusBar(statusBar statusBar) {
-	u.statBar = statusBar
-}
-
-func (u *UiUpdater) GetCurrentMode() string {
-	if u.statBar != nil {
-		return u.statBar.GetCurrentMode()
-	}
-	return ""
-}
-
-func (u *UiUpdater) UpdateStatusBarConfig(config interface{}) {
-	if u.statBar != nil {
-		u.statBar.UpdateConfig(config)
-	}
-}
-
 func (u *UiUpdater) UpdateUiData(fn func()) {
 	// Implementation for queuing a UI update (without redraw)
 	u.app.QueueUpdate(fn)
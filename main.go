@@ -1,26 +1,34 @@
 package main
 
 import (
-	"log"
+	"flag"
+	"fmt"
 	app "mihomoTui/internal"
 	"mihomoTui/internal/utils"
+	"os"
 )
 
 func main() {
+	profile := flag.String("profile", "", "controller profile to connect to for this run; the saved default is kept")
+	flag.Parse()
+
 	// Create new application with build info
 	app := app.NewApp(
 		utils.GetEnvWithDefault("APP_NAME", "mihomoTui"),
 		utils.GetEnvWithDefault("APP_VERSION", "v0.0-Alpha"),
+		*profile,
 	)
 
 	// Initialize the application
 	if err := app.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize application: %v", err)
+		fmt.Fprintf(os.Stderr, "Failed to initialize application: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Run the application
 	if err := app.Run(); err != nil {
 		app.Stop()
-		log.Fatalf("Failed to run application: %v", err)
+		fmt.Fprintf(os.Stderr, "Failed to run application: %v\n", err)
+		os.Exit(1)
 	}
 }